@@ -24,6 +24,7 @@ func main() {
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(tasks.TypeForecastAlertEmail, tasks.HandleForecastAlertEmailTask)
 	mux.HandleFunc(tasks.TypeOvernightEmail, tasks.HandleOvernightAlertEmailTask)
+	mux.HandleFunc(tasks.TypeAvalancheAlertEmail, tasks.HandleAvalancheAlertEmailTask)
 
 	if err := srv.Run(mux); err != nil {
 		log.Fatal(err)
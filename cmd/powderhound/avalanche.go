@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"powderhoundgo/internal/scraping"
+)
+
+func runAvalancheZones(args []string) int {
+	flags := flag.NewFlagSet("avalanche zones", flag.ExitOnError)
+	out := flags.String("out", "config/avalanche-zones.geojson", "file the forecast zones are written to")
+	flags.Parse(args)
+
+	zones, err := scraping.FetchForecastZones(scraping.NewCAICClient(), scraping.NewAvalancheOrgClient(), time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "avalanche zones: %v\n", err)
+		return 1
+	}
+	data, err := zones.GeoJSON()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "avalanche zones: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "avalanche zones: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %d forecast zones to %s\n", len(zones), *out)
+	return 0
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"powderhoundgo/internal/configlint"
+	"powderhoundgo/internal/configsource"
+)
+
+func runConfigLint(args []string) int {
+	flags := flag.NewFlagSet("config lint", flag.ExitOnError)
+	configDir := flags.String("config-dir", "config", "directory containing the resort scraping configs")
+	flags.Parse(args)
+
+	var issues []configlint.Issue
+	if flags.NArg() > 0 {
+		issues = configlint.LintFiles(flags.Args())
+	} else {
+		var err error
+		issues, err = configlint.LintDir(*configDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "config lint: %v\n", err)
+			return 1
+		}
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if errors := configlint.Errors(issues); errors > 0 {
+		fmt.Fprintf(os.Stderr, "%d issue(s) found\n", errors)
+		return 1
+	}
+	return 0
+}
+
+func runConfigResolve(args []string) int {
+	flags := flag.NewFlagSet("config resolve", flag.ExitOnError)
+	configDir := flags.String("config-dir", "config", "directory containing the resort scraping configs")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "config resolve: at least one mountain name is required")
+		return 2
+	}
+
+	source := configsource.NewDirSource(*configDir)
+	exitCode := 0
+	for _, mountain := range flags.Args() {
+		data, err := configsource.Resolve(source, mountain)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", mountain, err)
+			exitCode = 1
+			continue
+		}
+		var out bytes.Buffer
+		json.Indent(&out, data, "", "  ")
+		fmt.Println(out.String())
+	}
+	return exitCode
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"powderhoundgo/internal/configsource"
+	"powderhoundgo/internal/scraping"
+)
+
+func runFixturesRecord(args []string) int {
+	flags := flag.NewFlagSet("fixtures record", flag.ExitOnError)
+	configDir := flags.String("config-dir", "config", "directory containing the resort scraping configs")
+	outDir := flags.String("out", "internal/scraping/testdata/fixtures", "directory the fixtures are written to")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "fixtures record: at least one mountain name is required")
+		return 2
+	}
+
+	exitCode := 0
+	for _, mountain := range flags.Args() {
+		config, err := configsource.Load(configsource.NewDirSource(*configDir), mountain)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", mountain, err)
+			exitCode = 1
+			continue
+		}
+
+		data, err := scraping.RecordResortFixtures(config, filepath.Join(*outDir, mountain))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: scrape failed while recording: %v\n", mountain, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Printf("%s: recorded base_depth=%v snow_past_24h=%v snow_past_48h=%v lifts_open=%v runs_open=%v\n",
+			mountain, data["base_depth"], data["snow_past_24h"], data["snow_past_48h"], data["lifts_open"], data["runs_open"])
+	}
+	return exitCode
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: powderhound <command> <subcommand> [flags] [args]
+
+Commands:
+  avalanche zones                 Download the avalanche forecast zones that mountains are grouped by
+  config lint [file]...           Validate scraping configs (defaults to every config in -config-dir)
+  config resolve <mountain>...    Print configs with the family templates they extend merged in
+  fixtures record <mountain>...   Scrape resorts and save their pages as offline test fixtures
+`
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, args := os.Args[1]+" "+os.Args[2], os.Args[3:]
+	switch command {
+	case "avalanche zones":
+		os.Exit(runAvalancheZones(args))
+	case "config lint":
+		os.Exit(runConfigLint(args))
+	case "config resolve":
+		os.Exit(runConfigResolve(args))
+	case "fixtures record":
+		os.Exit(runFixturesRecord(args))
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n%s", command, usage)
+		os.Exit(2)
+	}
+}
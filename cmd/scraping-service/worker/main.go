@@ -3,12 +3,29 @@ package main
 import (
 	"log"
 	"os"
+	"powderhoundgo/internal/artifacts"
+	"powderhoundgo/internal/browserpool"
+	"powderhoundgo/internal/scraping"
 	"powderhoundgo/internal/tasks"
 	"powderhoundgo/internal/util"
+	"strconv"
 
 	"github.com/hibiken/asynq"
 )
 
+const (
+	defaultBrowserPoolSize    = 2
+	defaultBrowserPoolMaxUses = 50
+)
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
 func main() {
 	util.LoadEnvironmentVariables()
 	redisHost := os.Getenv("REDIS_HOST")
@@ -16,6 +33,17 @@ func main() {
 		redisHost = "localhost"
 	}
 
+	// Scraping tasks share a few long-lived browsers instead of starting Chrome for every task
+	poolSize := getEnvInt("BROWSER_POOL_SIZE", defaultBrowserPoolSize)
+	maxUses := getEnvInt("BROWSER_POOL_MAX_USES", defaultBrowserPoolMaxUses)
+	pool := browserpool.NewPool(poolSize, maxUses)
+	defer pool.Close()
+	scraping.UseBrowserPool(pool)
+	log.Printf("Browser pool size: %d, max uses per browser: %d", poolSize, maxUses)
+
+	// Failed scrapes save a screenshot, the DOM and the browser log for debugging
+	scraping.UseArtifactStore(artifacts.NewStore())
+
 	redisOpts := asynq.RedisClientOpt{Addr: redisHost + ":6379", Password: "", DB: 0}
 	srv := asynq.NewServer(redisOpts, asynq.Config{
 		Concurrency: 0,
@@ -24,6 +52,7 @@ func main() {
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(tasks.TypeResortWebScrapingJob, tasks.HandleResortWebScrapeTask)
 	mux.HandleFunc(tasks.TypeAvalancheScrapingJob, tasks.HandleAvalancheScrapingTask)
+	mux.HandleFunc(tasks.TypeHistoryPruneJob, tasks.HandleHistoryPruneTask)
 
 	if err := srv.Run(mux); err != nil {
 		log.Fatal(err)
@@ -0,0 +1,9 @@
+// Package config embeds the resort scraping configs and their family
+// templates, so a binary can load them without the config bucket or the
+// config directory, along with the avalanche forecast zones
+package config
+
+import "embed"
+
+//go:embed *.json families/*.json avalanche-zones.geojson
+var Files embed.FS
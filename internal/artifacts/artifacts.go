@@ -0,0 +1,77 @@
+package artifacts
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	storage_go "github.com/supabase-community/storage-go"
+)
+
+const (
+	defaultBucket = "scrape-artifacts"
+	defaultDir    = "artifacts"
+)
+
+// Store saves the files captured when a scrape fails. Keys are slash
+// separated paths, e.g. "vail/20240101T060000Z/screenshot.png"
+type Store interface {
+	Save(key string, contentType string, data []byte) error
+}
+
+// NewStore returns the Supabase storage bucket in production and a local
+// directory everywhere else. SCRAPE_ARTIFACT_BUCKET and SCRAPE_ARTIFACT_DIR
+// override the defaults
+func NewStore() Store {
+	if os.Getenv("ENV") == "production" {
+		bucket := os.Getenv("SCRAPE_ARTIFACT_BUCKET")
+		if bucket == "" {
+			bucket = defaultBucket
+		}
+		storageUrl := fmt.Sprintf("%s/storage/v1", os.Getenv("SUPABASE_URL"))
+		client := storage_go.NewClient(storageUrl, os.Getenv("SUPABASE_SERVICE_ROLE_KEY"), nil)
+		return &BucketStore{client: client, bucket: bucket}
+	}
+
+	dir := os.Getenv("SCRAPE_ARTIFACT_DIR")
+	if dir == "" {
+		dir = defaultDir
+	}
+	return &LocalStore{Dir: dir}
+}
+
+// LocalStore writes artifacts under a directory on disk
+type LocalStore struct {
+	Dir string
+}
+
+func (s *LocalStore) Save(key string, contentType string, data []byte) error {
+	path := filepath.Join(s.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write artifact %s: %w", key, err)
+	}
+	log.Printf("Saved artifact: %s", path)
+	return nil
+}
+
+// BucketStore uploads artifacts to a Supabase storage bucket
+type BucketStore struct {
+	client *storage_go.Client
+	bucket string
+}
+
+func (s *BucketStore) Save(key string, contentType string, data []byte) error {
+	_, err := s.client.UploadFile(s.bucket, key, bytes.NewReader(data), storage_go.FileOptions{
+		ContentType: &contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload artifact %s: %w", key, err)
+	}
+	log.Printf("Uploaded artifact: %s/%s", s.bucket, key)
+	return nil
+}
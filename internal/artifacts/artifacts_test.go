@@ -0,0 +1,33 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStoreSave(t *testing.T) {
+	store := &LocalStore{Dir: t.TempDir()}
+
+	err := store.Save("vail/20240101T060000Z/dom.html", "text/html", []byte("<html></html>"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(store.Dir, "vail", "20240101T060000Z", "dom.html"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "<html></html>", string(data))
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	t.Setenv("ENV", "development")
+	t.Setenv("SCRAPE_ARTIFACT_DIR", "/tmp/powderhound-artifacts")
+	assert.Equal(t, &LocalStore{Dir: "/tmp/powderhound-artifacts"}, NewStore())
+
+	t.Setenv("ENV", "production")
+	_, ok := NewStore().(*BucketStore)
+	assert.True(t, ok, "expected a BucketStore in production")
+}
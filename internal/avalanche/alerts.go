@@ -0,0 +1,85 @@
+package avalanche
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"powderhoundgo/internal/sanitize"
+	"powderhoundgo/internal/scraping"
+	"powderhoundgo/internal/supabase"
+)
+
+const (
+	// AlertDangerLevel is the overall danger that always triggers an alert,
+	// Considerable
+	AlertDangerLevel = 3
+
+	// Alerts are sent once a day, so only forecasts issued in the day before
+	// are alerted on
+	alertWindow = 24 * time.Hour
+	// How far back to look for the forecast before the latest, to tell
+	// whether the danger rose
+	alertLookback = 7 * 24 * time.Hour
+)
+
+// Alert is a mountain's latest forecast when its danger is worth an email
+type Alert struct {
+	MountainID int
+	IssueDate  time.Time
+	Level      int
+	// Overall danger of the forecast before it, 0 when there isn't one
+	PreviousLevel int
+	// Every forecast day, today's first
+	DangerLevels []scraping.AvalancheDangerLevel
+	Summary      string
+	ForecastURL  string
+}
+
+// Rose reports whether the danger went up since the previous forecast
+func (a *Alert) Rose() bool {
+	return a.PreviousLevel > 0 && a.Level > a.PreviousLevel
+}
+
+// CheckAlert returns an alert for the mountain's latest forecast when it was
+// issued in the last day and its overall danger reached threshold or rose
+// since the forecast before it. It returns nil when there's nothing to alert
+func CheckAlert(client supabase.SupabaseClient, mountainID int, threshold int, now time.Time) (*Alert, error) {
+	versions, err := client.GetAvalancheForecastVersions(mountainID, now.Add(-alertLookback))
+	if err != nil {
+		return nil, err
+	}
+	return checkVersions(versions, threshold, now)
+}
+
+// Versions are oldest first
+func checkVersions(versions []supabase.AvalancheForecastVersion, threshold int, now time.Time) (*Alert, error) {
+	if len(versions) == 0 {
+		return nil, nil
+	}
+	latest := versions[len(versions)-1]
+	if now.Sub(latest.IssueDate) > alertWindow {
+		return nil, nil
+	}
+
+	alert := &Alert{
+		MountainID:  latest.MountainID,
+		IssueDate:   latest.IssueDate,
+		Level:       latest.OverallDangerLevel,
+		Summary:     sanitize.PlainText(latest.AvalancheSummary),
+		ForecastURL: latest.ForecastURL,
+	}
+	if len(versions) > 1 {
+		alert.PreviousLevel = versions[len(versions)-2].OverallDangerLevel
+	}
+	if alert.Level < threshold && !alert.Rose() {
+		return nil, nil
+	}
+
+	if len(latest.DangerLevels) > 0 {
+		if err := json.Unmarshal(latest.DangerLevels, &alert.DangerLevels); err != nil {
+			return nil, fmt.Errorf("failed to parse danger levels of mountain %d: %w", latest.MountainID, err)
+		}
+	}
+	return alert, nil
+}
@@ -0,0 +1,83 @@
+package avalanche
+
+import (
+	"testing"
+	"time"
+
+	"powderhoundgo/internal/scraping"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAlert(t *testing.T) {
+	now := issued.Add(3 * time.Hour)
+
+	record := func(client *fakeClient, issueDate time.Time, level int) {
+		_, err := Record(client, forecast(issueDate, level))
+		assert.NoError(t, err)
+	}
+
+	t.Run("danger at the threshold", func(t *testing.T) {
+		client := newFakeClient()
+		record(client, issued.Add(-24*time.Hour), 3)
+		record(client, issued, 3)
+
+		alert, err := CheckAlert(client, 7, AlertDangerLevel, now)
+		if !assert.NoError(t, err) || !assert.NotNil(t, alert) {
+			return
+		}
+		assert.Equal(t, 3, alert.Level)
+		assert.False(t, alert.Rose())
+		assert.Equal(t, "Wind slabs", alert.Summary)
+		assert.Equal(t, []scraping.AvalancheDangerLevel{
+			{Date: "2024-01-11", OverallDangerLevel: 3, AboveTreeline: scraping.AvalancheRating{Level: 3, Rating: "Considerable"}},
+		}, alert.DangerLevels)
+	})
+
+	t.Run("danger rose below the threshold", func(t *testing.T) {
+		client := newFakeClient()
+		record(client, issued.Add(-24*time.Hour), 1)
+		record(client, issued, 2)
+
+		alert, err := CheckAlert(client, 7, AlertDangerLevel, now)
+		if !assert.NoError(t, err) || !assert.NotNil(t, alert) {
+			return
+		}
+		assert.True(t, alert.Rose())
+		assert.Equal(t, 1, alert.PreviousLevel)
+	})
+
+	t.Run("danger steady below the threshold", func(t *testing.T) {
+		client := newFakeClient()
+		record(client, issued.Add(-24*time.Hour), 2)
+		record(client, issued, 2)
+
+		alert, err := CheckAlert(client, 7, AlertDangerLevel, now)
+		assert.NoError(t, err)
+		assert.Nil(t, alert)
+	})
+
+	t.Run("first forecast of the season below the threshold", func(t *testing.T) {
+		client := newFakeClient()
+		record(client, issued, 2)
+
+		alert, err := CheckAlert(client, 7, AlertDangerLevel, now)
+		assert.NoError(t, err)
+		assert.Nil(t, alert)
+	})
+
+	t.Run("latest forecast was already alerted on", func(t *testing.T) {
+		client := newFakeClient()
+		record(client, issued, 4)
+
+		alert, err := CheckAlert(client, 7, AlertDangerLevel, issued.Add(30*time.Hour))
+		assert.NoError(t, err)
+		assert.Nil(t, alert)
+	})
+
+	t.Run("no forecasts", func(t *testing.T) {
+		alert, err := CheckAlert(newFakeClient(), 7, AlertDangerLevel, now)
+		assert.NoError(t, err)
+		assert.Nil(t, alert)
+	})
+}
@@ -0,0 +1,143 @@
+package avalanche
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"time"
+
+	"powderhoundgo/internal/scraping"
+	"powderhoundgo/internal/supabase"
+)
+
+// Row converts a forecast into its avalanche_forecasts row
+func Row(forecast *scraping.AvalancheForecast) map[string]interface{} {
+	return map[string]interface{}{
+		"mountain_id":                forecast.MountainID,
+		"avalanche_summary":          forecast.AvalancheSummary,
+		"avalanche_summary_text":     forecast.SummaryText,
+		"avalanche_summary_markdown": forecast.SummaryMarkdown,
+		"issue_date":                 forecast.IssueDate,
+		"overall_danger_level":       forecast.OverallDangerLevel,
+		"danger_levels":              forecast.DangerLevels,
+		"avalanche_problems":         forecast.Problems,
+		"forecast_url":               forecast.ForecastURL,
+		"zone_id":                    forecast.ZoneID,
+		"updated_at":                 forecast.UpdatedAt,
+	}
+}
+
+// Version converts a forecast into its avalanche_forecast_versions row
+func Version(forecast *scraping.AvalancheForecast) (supabase.AvalancheForecastVersion, error) {
+	version := supabase.AvalancheForecastVersion{
+		MountainID:         forecast.MountainID,
+		IssueDate:          forecast.IssueDate,
+		OverallDangerLevel: forecast.OverallDangerLevel,
+		AvalancheSummary:   forecast.AvalancheSummary,
+		ForecastURL:        forecast.ForecastURL,
+		ZoneID:             forecast.ZoneID,
+		RecordedAt:         forecast.UpdatedAt.UTC(),
+	}
+	var err error
+	if version.DangerLevels, err = json.Marshal(forecast.DangerLevels); err != nil {
+		return version, err
+	}
+	if version.AvalancheProblems, err = json.Marshal(forecast.Problems); err != nil {
+		return version, err
+	}
+	return version, nil
+}
+
+// Record saves a scraped forecast when it was issued after the one stored for
+// the mountain, adding it to the mountain's versions first so a failed upsert
+// is retried without losing the version. Centers publish once or twice a day,
+// so most hourly scrapes write nothing. A forecast without an issue date is
+// compared with the stored version instead, and dated when it was scraped
+// only when it differs. Reports whether the forecast was saved
+func Record(client supabase.SupabaseClient, forecast *scraping.AvalancheForecast) (bool, error) {
+	stored, err := client.GetAvalancheForecastIssueDate(forecast.MountainID)
+	if err != nil {
+		return false, err
+	}
+	if forecast.IssueDate.IsZero() {
+		unchanged, err := matchesStored(client, forecast, stored)
+		if err != nil {
+			return false, err
+		}
+		if unchanged {
+			log.Printf("Avalanche forecast for mountain %d has no issue date and matches the stored one, skipping upsert", forecast.MountainID)
+			return false, nil
+		}
+		dated := *forecast
+		dated.IssueDate = forecast.UpdatedAt
+		forecast = &dated
+	}
+	// An older forecast, e.g. from a fallback provider or a stale page,
+	// doesn't replace a newer one
+	if stored != nil && !forecast.IssueDate.After(*stored) {
+		log.Printf("Avalanche forecast for mountain %d issued %s isn't newer than the stored one from %s, skipping upsert", forecast.MountainID, forecast.IssueDate.Format(time.RFC3339), stored.Format(time.RFC3339))
+		return false, nil
+	}
+
+	version, err := Version(forecast)
+	if err != nil {
+		return false, err
+	}
+	if err := client.UpsertAvalancheForecastVersion(version); err != nil {
+		return false, err
+	}
+	if err := client.UpsertAvalancheForecast(Row(forecast)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reports whether the forecast has the same content as the mountain's
+// version issued at stored
+func matchesStored(client supabase.SupabaseClient, forecast *scraping.AvalancheForecast, stored *time.Time) (bool, error) {
+	if stored == nil {
+		return false, nil
+	}
+	versions, err := client.GetAvalancheForecastVersions(forecast.MountainID, *stored)
+	if err != nil || len(versions) == 0 {
+		return false, err
+	}
+	latest := versions[0]
+	version, err := Version(forecast)
+	if err != nil {
+		return false, err
+	}
+	return latest.AvalancheSummary == version.AvalancheSummary &&
+		latest.OverallDangerLevel == version.OverallDangerLevel &&
+		jsonEqual(latest.DangerLevels, version.DangerLevels) &&
+		jsonEqual(latest.AvalancheProblems, version.AvalancheProblems), nil
+}
+
+// Stored JSON comes back formatted by the database, so it's compared by value
+func jsonEqual(a, b json.RawMessage) bool {
+	var aValue, bValue interface{}
+	if json.Unmarshal(a, &aValue) != nil || json.Unmarshal(b, &bValue) != nil {
+		return false
+	}
+	return reflect.DeepEqual(aValue, bValue)
+}
+
+// DangerPoint is the overall danger of one issued forecast
+type DangerPoint struct {
+	IssueDate time.Time
+	Level     int
+}
+
+// DangerTrend returns the mountain's overall danger for each forecast issued
+// at or after since, oldest first
+func DangerTrend(client supabase.SupabaseClient, mountainID int, since time.Time) ([]DangerPoint, error) {
+	versions, err := client.GetAvalancheForecastVersions(mountainID, since)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]DangerPoint, len(versions))
+	for i, version := range versions {
+		points[i] = DangerPoint{IssueDate: version.IssueDate, Level: version.OverallDangerLevel}
+	}
+	return points, nil
+}
@@ -0,0 +1,168 @@
+package avalanche
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"powderhoundgo/internal/scraping"
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// In-memory avalanche_forecasts and avalanche_forecast_versions tables.
+// Methods the avalanche package doesn't use panic through the nil embedded
+// client
+type fakeClient struct {
+	supabase.SupabaseClient
+	forecasts  map[int]map[string]interface{}
+	versions   []supabase.AvalancheForecastVersion
+	upsertErr  error
+	upsertions int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{forecasts: map[int]map[string]interface{}{}}
+}
+
+func (f *fakeClient) GetAvalancheForecastIssueDate(mountainID int) (*time.Time, error) {
+	row, ok := f.forecasts[mountainID]
+	if !ok {
+		return nil, nil
+	}
+	issueDate := row["issue_date"].(time.Time)
+	return &issueDate, nil
+}
+
+func (f *fakeClient) UpsertAvalancheForecast(data map[string]interface{}) error {
+	f.upsertions++
+	if f.upsertErr != nil {
+		return f.upsertErr
+	}
+	f.forecasts[data["mountain_id"].(int)] = data
+	return nil
+}
+
+func (f *fakeClient) UpsertAvalancheForecastVersion(version supabase.AvalancheForecastVersion) error {
+	for i, existing := range f.versions {
+		if existing.MountainID == version.MountainID && existing.IssueDate.Equal(version.IssueDate) {
+			f.versions[i] = version
+			return nil
+		}
+	}
+	f.versions = append(f.versions, version)
+	return nil
+}
+
+func (f *fakeClient) GetAvalancheForecastVersions(mountainID int, since time.Time) ([]supabase.AvalancheForecastVersion, error) {
+	var versions []supabase.AvalancheForecastVersion
+	for _, version := range f.versions {
+		if version.MountainID == mountainID && !version.IssueDate.Before(since) {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+var issued = time.Date(2024, 1, 10, 16, 0, 0, 0, time.FixedZone("MST", -7*60*60))
+
+func forecast(issueDate time.Time, level int) *scraping.AvalancheForecast {
+	return &scraping.AvalancheForecast{
+		MountainID:         7,
+		AvalancheSummary:   "<p>Wind slabs</p>",
+		IssueDate:          issueDate,
+		OverallDangerLevel: level,
+		DangerLevels: []scraping.AvalancheDangerLevel{
+			{Date: "2024-01-11", OverallDangerLevel: level, AboveTreeline: scraping.AvalancheRating{Level: level, Rating: "Considerable"}},
+		},
+		UpdatedAt: issueDate.Add(time.Hour),
+	}
+}
+
+func TestRecord(t *testing.T) {
+	client := newFakeClient()
+
+	saved, err := Record(client, forecast(issued, 3))
+	assert.NoError(t, err)
+	assert.True(t, saved)
+
+	// The hourly scrape finds the same forecast, in UTC this time
+	saved, err = Record(client, forecast(issued.UTC(), 3))
+	assert.NoError(t, err)
+	assert.False(t, saved)
+	assert.Equal(t, 1, client.upsertions)
+
+	saved, err = Record(client, forecast(issued.Add(24*time.Hour), 2))
+	assert.NoError(t, err)
+	assert.True(t, saved)
+	assert.Equal(t, 2, client.upsertions)
+
+	// A fallback provider still serving the day before's forecast
+	saved, err = Record(client, forecast(issued, 3))
+	assert.NoError(t, err)
+	assert.False(t, saved)
+	assert.Equal(t, 2, client.upsertions)
+	assert.Equal(t, issued.Add(24*time.Hour), client.forecasts[7]["issue_date"])
+
+	if assert.Len(t, client.versions, 2) {
+		assert.JSONEq(t, `[{"date": "2024-01-11", "overall_danger_level": 3, "above_treeline": {"level": 3, "rating": "Considerable"}, "near_treeline": {"level": 0, "rating": ""}, "below_treeline": {"level": 0, "rating": ""}}]`, string(client.versions[0].DangerLevels))
+		assert.JSONEq(t, `null`, string(client.versions[0].AvalancheProblems))
+		assert.Equal(t, 2, client.versions[1].OverallDangerLevel)
+	}
+
+	trend, err := DangerTrend(client, 7, issued)
+	assert.NoError(t, err)
+	assert.Equal(t, []DangerPoint{
+		{IssueDate: issued, Level: 3},
+		{IssueDate: issued.Add(24 * time.Hour), Level: 2},
+	}, trend)
+}
+
+func TestRecordRetriesFailedUpsert(t *testing.T) {
+	client := newFakeClient()
+	client.upsertErr = errors.New("connection reset")
+
+	_, err := Record(client, forecast(issued, 3))
+	assert.Error(t, err)
+	assert.Len(t, client.versions, 1, "the version is kept before the upsert")
+
+	client.upsertErr = nil
+	saved, err := Record(client, forecast(issued, 3))
+	assert.NoError(t, err)
+	assert.True(t, saved)
+	assert.Len(t, client.versions, 1, "retrying doesn't add the version twice")
+}
+
+func TestRecordWithoutIssueDate(t *testing.T) {
+	client := newFakeClient()
+	scraped := issued.Add(3 * time.Hour)
+
+	undated := func(level int) *scraping.AvalancheForecast {
+		f := forecast(time.Time{}, level)
+		f.UpdatedAt = scraped
+		return f
+	}
+
+	saved, err := Record(client, undated(3))
+	assert.NoError(t, err)
+	assert.True(t, saved, "the first forecast is saved")
+	assert.True(t, client.versions[0].IssueDate.Equal(scraped), "dated when it was scraped")
+
+	// Hourly scrapes of the same forecast write nothing
+	scraped = scraped.Add(time.Hour)
+	saved, err = Record(client, undated(3))
+	assert.NoError(t, err)
+	assert.False(t, saved)
+	assert.Equal(t, 1, client.upsertions)
+	assert.Len(t, client.versions, 1)
+
+	// A changed forecast is a new version
+	saved, err = Record(client, undated(4))
+	assert.NoError(t, err)
+	assert.True(t, saved)
+	if assert.Len(t, client.versions, 2) {
+		assert.True(t, client.versions[1].IssueDate.Equal(scraped))
+		assert.Equal(t, 4, client.versions[1].OverallDangerLevel)
+	}
+}
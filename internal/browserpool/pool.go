@@ -0,0 +1,192 @@
+package browserpool
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+)
+
+var ErrPoolClosed = errors.New("browser pool is closed")
+
+// Pool keeps a fixed number of Chrome processes alive between scraping tasks
+// and hands out a fresh incognito browser context for every task, so tasks
+// share a process but never cookies, storage or cache.
+//
+// A browser is replaced once it has served MaxUses tasks or when its
+// connection is lost (e.g. the process crashed)
+type Pool struct {
+	mu       sync.Mutex
+	browsers []*instance
+	maxUses  int
+	opts     []chromedp.ExecAllocatorOption
+	closed   bool
+}
+
+type instance struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	lost     <-chan struct{}
+	uses     int
+	active   int
+	retiring bool
+}
+
+// NewPool creates a pool of size browsers, each restarted after maxUses tasks.
+// Browsers are started lazily the first time they are needed. A maxUses of 0
+// never restarts a healthy browser
+func NewPool(size, maxUses int, opts ...chromedp.ExecAllocatorOption) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	if len(opts) == 0 {
+		opts = chromedp.DefaultExecAllocatorOptions[:]
+	}
+	return &Pool{
+		browsers: make([]*instance, size),
+		maxUses:  maxUses,
+		opts:     opts,
+	}
+}
+
+func (i *instance) isAlive() bool {
+	select {
+	case <-i.lost:
+		return false
+	case <-i.ctx.Done():
+		return false
+	default:
+		return true
+	}
+}
+
+func (p *Pool) startBrowser() (*instance, error) {
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), p.opts...)
+	ctx, cancelCtx := chromedp.NewContext(allocCtx, chromedp.WithLogf(log.Printf))
+	cancel := func() {
+		cancelCtx()
+		cancelAlloc()
+	}
+
+	// Running with no actions starts the browser process
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	log.Printf("Started pooled browser")
+	return &instance{
+		ctx:    ctx,
+		cancel: cancel,
+		lost:   chromedp.FromContext(ctx).Browser.LostConnection,
+	}, nil
+}
+
+// Picks the least busy browser, replacing any slot whose browser has crashed
+// or used up its allowance. Must be called with p.mu held
+func (p *Pool) acquire() (*instance, error) {
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+
+	slot := -1
+	for i, browser := range p.browsers {
+		if browser != nil && !browser.isAlive() {
+			log.Printf("Pooled browser lost its connection, restarting")
+			p.retire(browser)
+			p.browsers[i] = nil
+		}
+		if p.browsers[i] == nil {
+			slot = i
+			break
+		}
+		if slot == -1 || p.browsers[i].active < p.browsers[slot].active {
+			slot = i
+		}
+	}
+
+	browser := p.browsers[slot]
+	if browser != nil && p.maxUses > 0 && browser.uses >= p.maxUses {
+		log.Printf("Pooled browser reached %d uses, restarting", browser.uses)
+		p.retire(browser)
+		browser = nil
+	}
+
+	if browser == nil {
+		var err error
+		browser, err = p.startBrowser()
+		if err != nil {
+			return nil, err
+		}
+		p.browsers[slot] = browser
+	}
+
+	browser.uses++
+	browser.active++
+	return browser, nil
+}
+
+// Stops a browser once its last task has finished. Must be called with p.mu held
+func (p *Pool) retire(browser *instance) {
+	browser.retiring = true
+	if browser.active == 0 {
+		browser.cancel()
+	}
+}
+
+func (p *Pool) release(browser *instance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	browser.active--
+	if browser.retiring && browser.active == 0 {
+		browser.cancel()
+	}
+}
+
+// NewTab returns a chromedp context for a single task, running in its own
+// incognito browser context on one of the pool's browsers. The tab is closed
+// when parent is done or the returned cancel func is called, whichever
+// happens first; cancel must always be called to return the browser to the pool
+func (p *Pool) NewTab(parent context.Context) (context.Context, context.CancelFunc, error) {
+	p.mu.Lock()
+	browser, err := p.acquire()
+	p.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tabCtx, cancelTab := chromedp.NewContext(browser.ctx, chromedp.WithNewBrowserContext())
+	go func() {
+		select {
+		case <-parent.Done():
+			cancelTab()
+		case <-tabCtx.Done():
+		}
+	}()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			cancelTab()
+			p.release(browser)
+		})
+	}
+	return tabCtx, cancel, nil
+}
+
+// Close stops every browser in the pool. Tabs that are still running are cancelled
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for i, browser := range p.browsers {
+		if browser != nil {
+			browser.cancel()
+			p.browsers[i] = nil
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package browserpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/chromedp"
+	"github.com/stretchr/testify/assert"
+)
+
+// Skips the test when no Chrome installation is available
+func newTestPool(t *testing.T, size, maxUses int) *Pool {
+	t.Helper()
+	pool := NewPool(size, maxUses)
+	t.Cleanup(pool.Close)
+
+	_, cancel, err := pool.NewTab(context.Background())
+	if err != nil {
+		t.Skipf("chrome is not available: %v", err)
+	}
+	cancel()
+	return pool
+}
+
+func runTab(t *testing.T, pool *Pool, actions ...chromedp.Action) *instance {
+	t.Helper()
+	ctx, cancel, err := pool.NewTab(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	pool.mu.Lock()
+	browser := pool.browsers[0]
+	pool.mu.Unlock()
+
+	ctx, timeoutCancel := context.WithTimeout(ctx, 30*time.Second)
+	defer timeoutCancel()
+	chromedp.Run(ctx, append([]chromedp.Action{chromedp.Navigate("about:blank")}, actions...)...)
+	return browser
+}
+
+func TestPoolRestartsAfterMaxUses(t *testing.T) {
+	pool := newTestPool(t, 1, 3)
+
+	first := runTab(t, pool)
+	second := runTab(t, pool)
+	assert.Same(t, first, second, "expected the browser to be reused")
+
+	third := runTab(t, pool)
+	assert.NotSame(t, first, third, "expected a new browser after max uses")
+}
+
+func TestPoolRestartsCrashedBrowser(t *testing.T) {
+	pool := newTestPool(t, 1, 0)
+
+	crashed := runTab(t, pool, browser.Crash())
+	assert.Eventually(t, func() bool { return !crashed.isAlive() }, 10*time.Second, 100*time.Millisecond)
+
+	restarted := runTab(t, pool)
+	assert.NotSame(t, crashed, restarted, "expected a new browser after a crash")
+	assert.True(t, restarted.isAlive())
+}
+
+func TestPoolClose(t *testing.T) {
+	pool := NewPool(1, 0)
+	pool.Close()
+
+	_, _, err := pool.NewTab(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
@@ -0,0 +1,415 @@
+package configlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"powderhoundgo/internal/configsource"
+	"powderhoundgo/internal/season"
+	"powderhoundgo/internal/supabase"
+
+	"github.com/andybalholm/cascadia"
+)
+
+// Issue is a single problem found in a scraping config file
+type Issue struct {
+	File    string
+	Field   string
+	Message string
+	// Warnings are reported without failing the lint
+	Warning bool
+}
+
+func (i Issue) String() string {
+	message := i.Message
+	if i.Warning {
+		message = "warning: " + message
+	}
+	if i.Field == "" {
+		return fmt.Sprintf("%s: %s", i.File, message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.File, i.Field, message)
+}
+
+// Errors counts the issues that aren't warnings
+func Errors(issues []Issue) int {
+	count := 0
+	for _, issue := range issues {
+		if !issue.Warning {
+			count++
+		}
+	}
+	return count
+}
+
+// LintDir lints every JSON config in dir
+func LintDir(dir string) ([]Issue, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	return LintFiles(paths), nil
+}
+
+// LintFiles lints each config file on its own, then checks for IDs shared between them
+func LintFiles(paths []string) []Issue {
+	var issues []Issue
+	filesByID := map[int][]string{}
+
+	for _, path := range paths {
+		config, fileIssues := LintFile(path)
+		issues = append(issues, fileIssues...)
+		if config != nil && config.ID != 0 {
+			filesByID[config.ID] = append(filesByID[config.ID], path)
+		}
+	}
+
+	for id, files := range filesByID {
+		if len(files) < 2 {
+			continue
+		}
+		for _, file := range files {
+			issues = append(issues, Issue{File: file, Field: "id", Message: fmt.Sprintf("id %d is also used by %s", id, strings.Join(otherFiles(files, file), ", "))})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].File < issues[j].File })
+	return issues
+}
+
+func otherFiles(files []string, exclude string) []string {
+	var others []string
+	for _, file := range files {
+		if file != exclude {
+			others = append(others, filepath.Base(file))
+		}
+	}
+	return others
+}
+
+// LintFile resolves the family template a single config extends, from the
+// families directory next to it, then strictly decodes and validates the
+// result. The decoded config is nil when the file could not be read or parsed
+func LintFile(path string) (*supabase.ScrapingConfig, []Issue) {
+	source := configsource.NewDirSource(filepath.Dir(path))
+	data, err := configsource.Resolve(source, strings.TrimSuffix(filepath.Base(path), ".json"))
+	if err != nil {
+		return nil, []Issue{{File: path, Message: err.Error()}}
+	}
+
+	var config supabase.ScrapingConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, []Issue{{File: path, Message: fmt.Sprintf("invalid config: %v", err)}}
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, []Issue{{File: path, Message: fmt.Sprintf("invalid config: %v", err)}}
+	}
+
+	var issues []Issue
+	for _, field := range unknownFields(raw, reflect.TypeOf(config), "") {
+		issues = append(issues, Issue{File: path, Field: field, Message: "unknown field"})
+	}
+	for _, issue := range LintConfig(config) {
+		issue.File = path
+		issues = append(issues, issue)
+	}
+	return &config, issues
+}
+
+// Returns the keys in raw (recursing into nested objects) that don't match a
+// json tag on t. encoding/json silently drops these when decoding
+func unknownFields(raw map[string]interface{}, t reflect.Type, prefix string) []string {
+	known := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			known[name] = field.Type
+		}
+	}
+
+	var unknown []string
+	for key, value := range raw {
+		fieldType, ok := known[key]
+		if !ok {
+			unknown = append(unknown, prefix+key)
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok && fieldType.Kind() == reflect.Struct {
+			unknown = append(unknown, unknownFields(nested, fieldType, prefix+key+".")...)
+		}
+		if items, ok := value.([]interface{}); ok && fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct {
+			for i, item := range items {
+				if nested, ok := item.(map[string]interface{}); ok {
+					unknown = append(unknown, unknownFields(nested, fieldType.Elem(), fmt.Sprintf("%s%s[%d].", prefix, key, i))...)
+				}
+			}
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// LintConfig validates a decoded config. Issues are returned without a file name
+func LintConfig(config supabase.ScrapingConfig) []Issue {
+	var issues []Issue
+	add := func(field, format string, args ...interface{}) {
+		issues = append(issues, Issue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if config.ID <= 0 {
+		add("id", "must be a positive mountain id")
+	}
+	if config.Name == "" {
+		add("name", "is required")
+	}
+	if _, err := season.Parse(config.SeasonCalendar()); err != nil {
+		add("season", "%v", err)
+	}
+	if config.ClosingDate != "" {
+		message := "is deprecated, move it to season.closing"
+		if config.Season.Closing != "" {
+			message = "is deprecated and ignored since season.closing is set"
+		}
+		issues = append(issues, Issue{Field: "closingDate", Message: message, Warning: true})
+	}
+
+	switch config.Unit {
+	case "", supabase.UnitInches, supabase.UnitFeet, supabase.UnitCentimeters:
+	default:
+		add("unit", "unknown unit %q, expected %s, %s or %s", config.Unit, supabase.UnitInches, supabase.UnitFeet, supabase.UnitCentimeters)
+	}
+
+	limits := []struct {
+		field string
+		value int
+	}{
+		{"validation.totalLifts", config.Validation.TotalLifts},
+		{"validation.totalRuns", config.Validation.TotalRuns},
+		{"validation.maxBaseDepthChange", config.Validation.MaxBaseDepthChange},
+	}
+	for _, limit := range limits {
+		if limit.value < 0 {
+			add(limit.field, "must not be negative")
+		}
+	}
+
+	switch config.Engine {
+	case "", supabase.EngineChromedp, supabase.EngineHTTP:
+		issues = append(issues, lintPageConfig(config)...)
+	case supabase.EngineJSON:
+		issues = append(issues, lintAPIConfig(config.API)...)
+	default:
+		add("engine", "unknown engine %q", config.Engine)
+	}
+
+	return issues
+}
+
+func lintPageConfig(config supabase.ScrapingConfig) []Issue {
+	var issues []Issue
+	add := func(field, format string, args ...interface{}) {
+		issues = append(issues, Issue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if config.ConditionsURL == "" {
+		add("conditionsURL", "is required")
+	}
+	if config.SeparateURLs && config.TerrainURL == "" {
+		add("terrainURL", "is required when separateURLs is true")
+	}
+	if config.Engine == supabase.EngineHTTP && config.ClickSelector != "" {
+		add("clickSelector", "is not supported by the %s engine", supabase.EngineHTTP)
+	}
+	if len(config.Steps) > 0 {
+		// The legacy flags are only translated into steps when a config declares none
+		if config.SeparateURLs {
+			add("separateURLs", "is ignored when steps are set")
+		}
+		if config.ClickSelector != "" {
+			add("clickSelector", "is ignored when steps are set")
+		}
+		if config.Terrain.RunClickInteraction {
+			add("terrain.runClickInteraction", "is ignored when steps are set")
+		}
+		issues = append(issues, lintSteps(config)...)
+	}
+
+	conditions, terrain := config.Conditions, config.Terrain
+	selectors := []struct {
+		field    string
+		selector string
+		required bool
+	}{
+		{"clickSelector", config.ClickSelector, false},
+		{"conditions.conditionsSelector", conditions.ConditionsSelector, true},
+		{"conditions.waitForSelector", conditions.WaitForSelector, false},
+		{"terrain.terrainSelector", terrain.TerrainSelector, !terrain.CountLifts || !terrain.CountRuns},
+		{"terrain.runsOpenSelector", terrain.RunsOpenSelector, !terrain.CountRuns},
+		{"terrain.liftsOpenSelector", terrain.LiftsOpenSelector, true},
+		{"terrain.liftStatusSelector", terrain.LiftStatusSelector, terrain.CountLifts},
+		{"terrain.runStatusSelector", terrain.RunStatusSelector, terrain.CountRuns},
+		{"terrain.runClickSelector", terrain.RunClickSelector, terrain.RunClickInteraction},
+	}
+	for _, s := range selectors {
+		if s.selector == "" {
+			if s.required {
+				add(s.field, "is required")
+			}
+			continue
+		}
+		if _, err := cascadia.ParseGroup(s.selector); err != nil {
+			add(s.field, "invalid CSS selector %q: %v", s.selector, err)
+		}
+	}
+
+	fields := []struct {
+		field    string
+		selector supabase.FieldSelector
+		required bool
+	}{
+		{"conditions.baseDepthSelector", conditions.BaseDepthSelector, true},
+		{"conditions.snowpackSelector", conditions.SnowpackSelector, false},
+		{"conditions.seasonTotalSelector", conditions.SeasonTotalSelector, false},
+		{"conditions.snow24Selector", conditions.Snow24Selector, true},
+		{"conditions.snow48Selector", conditions.Snow48Selector, true},
+		{"conditions.snow7DaySelector", conditions.Snow7DaySelector, false},
+	}
+	for _, f := range fields {
+		issues = append(issues, lintFieldSelector(f.field, f.selector, f.required)...)
+	}
+
+	if terrain.RunClickInteraction && !terrain.CountRuns {
+		add("terrain.runClickInteraction", "has no effect unless countRuns is true")
+	}
+	if terrain.RunClickSelector != "" && !terrain.RunClickInteraction {
+		add("terrain.runClickSelector", "is ignored unless runClickInteraction is true")
+	}
+	if terrain.SumRunsFromMultipleSources && terrain.CountRuns {
+		add("terrain.sumRunsFromMultipleSources", "has no effect when countRuns is true")
+	}
+
+	return issues
+}
+
+func lintFieldSelector(field string, selector supabase.FieldSelector, required bool) []Issue {
+	var issues []Issue
+	add := func(format string, args ...interface{}) {
+		issues = append(issues, Issue{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !selector.IsSet() {
+		if required {
+			add("is required")
+		}
+		if selector.Pattern != "" {
+			add("pattern is set without any selectors")
+		}
+		return issues
+	}
+	for _, s := range selector.Selectors {
+		if _, err := cascadia.ParseGroup(s); err != nil {
+			add("invalid CSS selector %q: %v", s, err)
+		}
+	}
+	if selector.Pattern != "" {
+		pattern, err := regexp.Compile(selector.Pattern)
+		if err != nil {
+			add("invalid pattern %q: %v", selector.Pattern, err)
+		} else if pattern.NumSubexp() == 0 {
+			add("pattern %q has no capture group", selector.Pattern)
+		}
+	}
+	return issues
+}
+
+func lintSteps(config supabase.ScrapingConfig) []Issue {
+	var issues []Issue
+	for i, step := range config.Steps {
+		field := fmt.Sprintf("steps[%d]", i)
+		add := func(name, format string, args ...interface{}) {
+			issues = append(issues, Issue{Field: field + "." + name, Message: fmt.Sprintf(format, args...)})
+		}
+
+		switch step.Before {
+		case "", supabase.StageConditions, supabase.StageTerrain:
+		default:
+			add("before", "unknown stage %q", step.Before)
+		}
+
+		needsSelector := false
+		switch step.Action {
+		case supabase.StepNavigate:
+			if step.URL == "" {
+				add("url", "is required by %s steps", step.Action)
+			}
+		case supabase.StepClick, supabase.StepClickAll, supabase.StepWaitVisible:
+			needsSelector = true
+		case supabase.StepSelectTab:
+			needsSelector = true
+			if step.Text == "" {
+				add("text", "is required by %s steps", step.Action)
+			}
+		case supabase.StepScroll:
+		case supabase.StepSleep:
+			if duration, err := time.ParseDuration(step.Duration); err != nil || duration <= 0 {
+				add("duration", "%q is not a positive duration such as \"2s\"", step.Duration)
+			}
+		default:
+			add("action", "unknown action %q", step.Action)
+			continue
+		}
+
+		if step.Selector == "" {
+			if needsSelector {
+				add("selector", "is required by %s steps", step.Action)
+			}
+		} else if _, err := cascadia.ParseGroup(step.Selector); err != nil {
+			add("selector", "invalid CSS selector %q: %v", step.Selector, err)
+		}
+
+		if config.Engine == supabase.EngineHTTP && (step.Action == supabase.StepClick || step.Action == supabase.StepSelectTab) {
+			add("action", "%s steps are not supported by the %s engine", step.Action, supabase.EngineHTTP)
+		}
+	}
+	return issues
+}
+
+func lintAPIConfig(api supabase.APIConfig) []Issue {
+	var issues []Issue
+	if api.URL == "" {
+		issues = append(issues, Issue{Field: "api.url", Message: fmt.Sprintf("is required by the %s engine", supabase.EngineJSON)})
+	}
+
+	paths := []struct {
+		field    string
+		path     string
+		required bool
+	}{
+		{"api.baseDepth", api.BaseDepth, true},
+		{"api.snowpack", api.Snowpack, false},
+		{"api.seasonTotal", api.SeasonTotal, false},
+		{"api.snow24", api.Snow24, true},
+		{"api.snow48", api.Snow48, true},
+		{"api.snow7Day", api.Snow7Day, false},
+		{"api.liftsOpen", api.LiftsOpen, false},
+		{"api.runsOpen", api.RunsOpen, false},
+	}
+	for _, p := range paths {
+		switch {
+		case p.path == "" && p.required:
+			issues = append(issues, Issue{Field: p.field, Message: "is required"})
+		case p.path != "" && !strings.HasPrefix(p.path, "$"):
+			issues = append(issues, Issue{Field: p.field, Message: fmt.Sprintf("JSONPath %q must start with $", p.path)})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,268 @@
+package configlint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validConfig() supabase.ScrapingConfig {
+	return supabase.ScrapingConfig{
+		ID:            1,
+		Name:          "Test Mountain",
+		ConditionsURL: "https://example.com/snow-report",
+		Conditions: supabase.ConditionsConfig{
+			ConditionsSelector: ".snow-report",
+			BaseDepthSelector:  supabase.NewFieldSelector(".base"),
+			Snow24Selector:     supabase.NewFieldSelector(".snow-24"),
+			Snow48Selector:     supabase.NewFieldSelector(".snow-48"),
+		},
+		Terrain: supabase.TerrainConfig{
+			TerrainSelector:   ".terrain",
+			RunsOpenSelector:  ".runs",
+			LiftsOpenSelector: ".lifts",
+		},
+	}
+}
+
+func issueFields(issues []Issue) []string {
+	fields := []string{}
+	for _, issue := range issues {
+		fields = append(fields, issue.Field)
+	}
+	return fields
+}
+
+func TestLintConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*supabase.ScrapingConfig)
+		want   []string
+	}{
+		{"valid config", func(c *supabase.ScrapingConfig) {}, []string{}},
+		{"invalid selector", func(c *supabase.ScrapingConfig) {
+			c.Conditions.BaseDepthSelector = supabase.NewFieldSelector("div.numbers-box:nth-child(2 > h5")
+		}, []string{"conditions.baseDepthSelector"}},
+		{"fallback selectors with a pattern", func(c *supabase.ScrapingConfig) {
+			c.Conditions.BaseDepthSelector = supabase.FieldSelector{
+				Selectors: []string{".base", "[data-stat='base']"},
+				Pattern:   `Base Depth:\s*(\d+)`,
+			}
+		}, []string{}},
+		{"invalid fallback selector and pattern", func(c *supabase.ScrapingConfig) {
+			c.Conditions.BaseDepthSelector = supabase.FieldSelector{
+				Selectors: []string{".base", "li:nth-child(2"},
+				Pattern:   `Base Depth:\s*(\d+`,
+			}
+			c.Conditions.Snow24Selector.Pattern = `\d+`
+			c.Conditions.SnowpackSelector.Pattern = `Surface: (.+)`
+		}, []string{"conditions.baseDepthSelector", "conditions.baseDepthSelector", "conditions.snowpackSelector", "conditions.snow24Selector"}},
+		{"separate URLs without terrain URL", func(c *supabase.ScrapingConfig) {
+			c.SeparateURLs = true
+		}, []string{"terrainURL"}},
+		{"run click interaction without counting runs", func(c *supabase.ScrapingConfig) {
+			c.Terrain.RunClickInteraction = true
+			c.Terrain.RunClickSelector = "a.toggle"
+		}, []string{"terrain.runClickInteraction"}},
+		{"counting lifts without status selector", func(c *supabase.ScrapingConfig) {
+			c.Terrain.CountLifts = true
+		}, []string{"terrain.liftStatusSelector"}},
+		{"counting runs without status selectors", func(c *supabase.ScrapingConfig) {
+			c.Terrain.CountRuns = true
+			c.Terrain.RunClickInteraction = true
+		}, []string{"terrain.runStatusSelector", "terrain.runClickSelector"}},
+		{"counting runs without runs open selector", func(c *supabase.ScrapingConfig) {
+			c.Terrain.CountRuns = true
+			c.Terrain.RunsOpenSelector = ""
+			c.Terrain.RunStatusSelector = ".trail .open"
+		}, []string{}},
+		{"summing runs that are counted", func(c *supabase.ScrapingConfig) {
+			c.Terrain.CountRuns = true
+			c.Terrain.SumRunsFromMultipleSources = true
+			c.Terrain.RunStatusSelector = ".trail .open"
+		}, []string{"terrain.sumRunsFromMultipleSources"}},
+		{"run click selector without interaction", func(c *supabase.ScrapingConfig) {
+			c.Terrain.RunClickSelector = "a.toggle"
+		}, []string{"terrain.runClickSelector"}},
+		{"unparseable closing date", func(c *supabase.ScrapingConfig) {
+			c.Season = supabase.SeasonConfig{Timezone: "America/Denver", Closing: "April 21st"}
+		}, []string{"season"}},
+		{"season dates without a timezone", func(c *supabase.ScrapingConfig) {
+			c.Season = supabase.SeasonConfig{Closing: "2024-04-21"}
+		}, []string{"season"}},
+		{"unknown timezone", func(c *supabase.ScrapingConfig) {
+			c.Season = supabase.SeasonConfig{Timezone: "MST7MDT/Denver"}
+		}, []string{"season"}},
+		{"valid season calendar", func(c *supabase.ScrapingConfig) {
+			c.Season = supabase.SeasonConfig{
+				Timezone:     "America/Denver",
+				Opening:      "2023-11-10",
+				Closing:      "2024-04-21",
+				Closures:     []supabase.DateRange{{Start: "2024-01-08", End: "2024-01-09"}},
+				WeekendsOnly: []supabase.DateRange{{Start: "2024-04-27", End: "2024-05-26"}},
+			}
+		}, []string{}},
+		{"metric unit", func(c *supabase.ScrapingConfig) {
+			c.Unit = supabase.UnitCentimeters
+		}, []string{}},
+		{"unknown unit", func(c *supabase.ScrapingConfig) {
+			c.Unit = "mm"
+		}, []string{"unit"}},
+		{"validation limits", func(c *supabase.ScrapingConfig) {
+			c.Validation = supabase.ValidationConfig{TotalLifts: 34, TotalRuns: 195, MaxBaseDepthChange: 18}
+		}, []string{}},
+		{"negative validation limits", func(c *supabase.ScrapingConfig) {
+			c.Validation = supabase.ValidationConfig{TotalLifts: -1, MaxBaseDepthChange: -6}
+		}, []string{"validation.totalLifts", "validation.maxBaseDepthChange"}},
+		{"unknown engine", func(c *supabase.ScrapingConfig) {
+			c.Engine = "selenium"
+		}, []string{"engine"}},
+		{"click selector with http engine", func(c *supabase.ScrapingConfig) {
+			c.Engine = supabase.EngineHTTP
+			c.ClickSelector = "button.report"
+		}, []string{"clickSelector"}},
+		{"valid steps", func(c *supabase.ScrapingConfig) {
+			c.Steps = []supabase.Step{
+				{Action: supabase.StepWaitVisible, Selector: ".snow-report"},
+				{Action: supabase.StepNavigate, Before: supabase.StageTerrain, URL: "https://example.com/terrain"},
+				{Action: supabase.StepSelectTab, Before: supabase.StageTerrain, Selector: ".tabs > button", Text: "Trails"},
+				{Action: supabase.StepScroll, Before: supabase.StageTerrain},
+				{Action: supabase.StepSleep, Before: supabase.StageTerrain, Duration: "2s"},
+			}
+		}, []string{}},
+		{"invalid steps", func(c *supabase.ScrapingConfig) {
+			c.Steps = []supabase.Step{
+				{Action: supabase.StepNavigate, Before: "lifts"},
+				{Action: supabase.StepClickAll, Selector: "a.toggle:nth-child("},
+				{Action: supabase.StepSelectTab},
+				{Action: supabase.StepSleep, Duration: "forever"},
+				{Action: "hover", Selector: ".menu"},
+			}
+		}, []string{"steps[0].before", "steps[0].url", "steps[1].selector", "steps[2].text", "steps[2].selector", "steps[3].duration", "steps[4].action"}},
+		{"legacy flags alongside steps", func(c *supabase.ScrapingConfig) {
+			c.ClickSelector = "button.report"
+			c.Steps = []supabase.Step{{Action: supabase.StepClick, Selector: "button.report"}}
+		}, []string{"clickSelector"}},
+		{"click step with http engine", func(c *supabase.ScrapingConfig) {
+			c.Engine = supabase.EngineHTTP
+			c.Steps = []supabase.Step{{Action: supabase.StepClick, Selector: "button.report"}}
+		}, []string{"steps[0].action"}},
+		{"json engine without api mappings", func(c *supabase.ScrapingConfig) {
+			c.Engine = supabase.EngineJSON
+			c.API.BaseDepth = "snowReport.base"
+		}, []string{"api.url", "api.baseDepth", "api.snow24", "api.snow48"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validConfig()
+			tt.modify(&config)
+			assert.Equal(t, tt.want, issueFields(LintConfig(config)))
+		})
+	}
+}
+
+func TestLintLegacyClosingDate(t *testing.T) {
+	config := validConfig()
+	config.ClosingDate = "2024-04-21 5:00pm (MST)"
+	issues := LintConfig(config)
+	if assert.Len(t, issues, 1) {
+		assert.Equal(t, "closingDate", issues[0].Field)
+		assert.True(t, issues[0].Warning)
+	}
+	assert.Equal(t, 0, Errors(issues))
+
+	config.ClosingDate = "April 21"
+	assert.Equal(t, []string{"season", "closingDate"}, issueFields(LintConfig(config)))
+}
+
+func TestLintFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	config := `{
+		"id": 7,
+		"name": "Test Mountain",
+		"conditionsURL": "https://example.com",
+		"conditions": {
+			"conditionsSelector": ".report",
+			"baseDepthSelector": ".base",
+			"snow24Selector": ".snow-24",
+			"snow48Selector": ".snow-48"
+		},
+		"terrain": {
+			"terrainSelector": ".terrain",
+			"runsOpenSelector": ".runs",
+			"liftsOpenSelector": ".lifts",
+			"liftDetailSelector": ""
+		},
+		"steps": [{"action": "scroll", "selecter": ".trails"}],
+		"closingdate": "2024-04-21 5:00pm (MST)"
+	}`
+	first := write("first.json", config)
+	second := write("second.json", config)
+	broken := write("broken.json", `{"id": "seven"}`)
+
+	issues := LintFiles([]string{first, second, broken})
+
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, issue.String())
+	}
+	assert.Len(t, issues, 11, messages)
+	assert.Contains(t, messages, first+": closingdate: unknown field")
+	assert.Contains(t, messages, first+": closingDate: warning: is deprecated, move it to season.closing")
+	assert.Contains(t, messages, first+": terrain.liftDetailSelector: unknown field")
+	assert.Contains(t, messages, first+": steps[0].selecter: unknown field")
+	assert.Contains(t, messages, first+": id: id 7 is also used by second.json")
+	assert.Contains(t, messages, second+": id: id 7 is also used by first.json")
+	assert.Equal(t, broken, issues[0].File)
+}
+
+func TestLintFileResolvesFamilyTemplate(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "families"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "families", "resorts.json"), []byte(`{
+		"conditions": {
+			"conditionsSelector": ".report",
+			"baseDepthSelector": ".base",
+			"snow24Selector": ".snow-24",
+			"snow48Selector": ".snow-48",
+			"snow72Selector": ".snow-72"
+		},
+		"terrain": {"terrainSelector": ".terrain", "runsOpenSelector": ".runs", "liftsOpenSelector": ".lifts"}
+	}`), 0o644))
+	resort := filepath.Join(dir, "resort.json")
+	assert.NoError(t, os.WriteFile(resort, []byte(`{
+		"id": 7,
+		"name": "Test Mountain",
+		"extends": "resorts",
+		"conditionsURL": "https://example.com"
+	}`), 0o644))
+	orphan := filepath.Join(dir, "orphan.json")
+	assert.NoError(t, os.WriteFile(orphan, []byte(`{"id": 8, "extends": "alterra"}`), 0o644))
+
+	config, issues := LintFile(resort)
+	assert.Equal(t, ".report", config.Conditions.ConditionsSelector)
+	assert.Len(t, issues, 1)
+	assert.Equal(t, "conditions.snow72Selector", issues[0].Field)
+
+	config, issues = LintFile(orphan)
+	assert.Nil(t, config)
+	assert.Len(t, issues, 1)
+	assert.Contains(t, issues[0].Message, "families/alterra")
+}
+
+func TestRepositoryConfigs(t *testing.T) {
+	issues, err := LintDir("../../config")
+	assert.NoError(t, err)
+	assert.Empty(t, issues)
+}
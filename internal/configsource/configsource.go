@@ -0,0 +1,220 @@
+package configsource
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"powderhoundgo/config"
+	"powderhoundgo/internal/supabase"
+
+	storage_go "github.com/supabase-community/storage-go"
+)
+
+const (
+	SourceDir      = "dir"
+	SourceBucket   = "bucket"
+	SourceEmbedded = "embedded"
+
+	defaultDir           = "config"
+	defaultBucket        = "scraping-config"
+	defaultDevBucket     = "scraping-config-dev"
+	defaultCacheDuration = 10 * time.Minute
+)
+
+// Source provides the resort scraping configs, one JSON document per resort
+type Source interface {
+	// Names lists the resorts with a config, e.g. "vail"
+	Names() ([]string, error)
+	// Read returns the raw JSON of a resort's config or of a family
+	// template, e.g. "families/vail-resorts"
+	Read(name string) ([]byte, error)
+}
+
+// NewSource returns the source picked by CONFIG_SOURCE: "dir" reads
+// CONFIG_DIR (./config by default), "embedded" uses the configs compiled into
+// the binary, and "bucket", the default, downloads from CONFIG_BUCKET
+// (scraping-config in production, scraping-config-dev elsewhere). Configs are
+// cached in memory for ten minutes, so each process keeps its own copy
+func NewSource() (Source, error) {
+	var source Source
+	switch kind := os.Getenv("CONFIG_SOURCE"); kind {
+	case SourceDir:
+		dir := os.Getenv("CONFIG_DIR")
+		if dir == "" {
+			dir = defaultDir
+		}
+		source = NewDirSource(dir)
+	case SourceEmbedded:
+		source = NewEmbeddedSource()
+	case SourceBucket, "":
+		bucket := os.Getenv("CONFIG_BUCKET")
+		if bucket == "" {
+			bucket = defaultDevBucket
+			if os.Getenv("ENV") == "production" {
+				bucket = defaultBucket
+			}
+		}
+		storageUrl := fmt.Sprintf("%s/storage/v1", os.Getenv("SUPABASE_URL"))
+		client := storage_go.NewClient(storageUrl, os.Getenv("SUPABASE_SERVICE_ROLE_KEY"), nil)
+		source = &BucketSource{client: client, bucket: bucket}
+	default:
+		return nil, fmt.Errorf("unknown CONFIG_SOURCE %q, expected %s, %s or %s", kind, SourceDir, SourceBucket, SourceEmbedded)
+	}
+	return NewCachedSource(source, defaultCacheDuration), nil
+}
+
+var (
+	defaultSource    Source
+	defaultSourceErr error
+	defaultOnce      sync.Once
+)
+
+// Default returns the process-wide source from NewSource, so every task in a
+// process shares its cache
+func Default() (Source, error) {
+	defaultOnce.Do(func() {
+		defaultSource, defaultSourceErr = NewSource()
+	})
+	return defaultSource, defaultSourceErr
+}
+
+// Load reads a resort's config, resolves the family template it extends and
+// decodes it
+func Load(source Source, name string) (supabase.ScrapingConfig, error) {
+	var config supabase.ScrapingConfig
+	data, err := Resolve(source, name)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse config %s: %w", name, err)
+	}
+	return config, nil
+}
+
+// FSSource reads configs from the top level of a file system, either a
+// directory on disk or the configs embedded in the binary
+type FSSource struct {
+	fsys fs.FS
+}
+
+func NewDirSource(dir string) *FSSource {
+	return &FSSource{fsys: os.DirFS(dir)}
+}
+
+func NewEmbeddedSource() *FSSource {
+	return &FSSource{fsys: config.Files}
+}
+
+func (s *FSSource) Names() ([]string, error) {
+	files, err := fs.Glob(s.fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, strings.TrimSuffix(file, ".json"))
+	}
+	return names, nil
+}
+
+func (s *FSSource) Read(name string) ([]byte, error) {
+	data, err := fs.ReadFile(s.fsys, name+".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// BucketSource downloads configs from a Supabase storage bucket
+type BucketSource struct {
+	client *storage_go.Client
+	bucket string
+}
+
+func (s *BucketSource) Names() ([]string, error) {
+	results, err := s.client.ListFiles(s.bucket, "", storage_go.FileSearchOptions{
+		Limit: 100,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs in %s: %w", s.bucket, err)
+	}
+	var names []string
+	for _, result := range results {
+		// Skips folder placeholders and anything else that isn't a config
+		if path.Ext(result.Name) == ".json" {
+			names = append(names, strings.TrimSuffix(result.Name, ".json"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *BucketSource) Read(name string) ([]byte, error) {
+	data, err := s.client.DownloadFile(s.bucket, name+".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download config %s from %s: %w", name, s.bucket, err)
+	}
+	return data, nil
+}
+
+// CachedSource keeps what another source returns for a while, so a queueing
+// pass or a run of tasks in one process reads each config once
+type CachedSource struct {
+	source   Source
+	duration time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	names   *cacheEntry
+	configs map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	names   []string
+	data    []byte
+	expires time.Time
+}
+
+func NewCachedSource(source Source, duration time.Duration) *CachedSource {
+	return &CachedSource{source: source, duration: duration, now: time.Now, configs: map[string]*cacheEntry{}}
+}
+
+func (s *CachedSource) Names() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.names != nil && s.now().Before(s.names.expires) {
+		return s.names.names, nil
+	}
+
+	names, err := s.source.Names()
+	if err != nil {
+		return nil, err
+	}
+	s.names = &cacheEntry{names: names, expires: s.now().Add(s.duration)}
+	return names, nil
+}
+
+// Read returns the cached config while it's fresh. Errors aren't cached, so
+// a failed download is retried on the next read
+func (s *CachedSource) Read(name string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.configs[name]; ok && s.now().Before(entry.expires) {
+		return entry.data, nil
+	}
+
+	data, err := s.source.Read(name)
+	if err != nil {
+		return nil, err
+	}
+	s.configs[name] = &cacheEntry{data: data, expires: s.now().Add(s.duration)}
+	return data, nil
+}
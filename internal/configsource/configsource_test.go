@@ -0,0 +1,118 @@
+package configsource
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Counts reads so tests can tell when the cache was used
+type countingSource struct {
+	Source
+	names int
+	reads map[string]int
+	err   error
+}
+
+func (s *countingSource) Names() ([]string, error) {
+	s.names++
+	return s.Source.Names()
+}
+
+func (s *countingSource) Read(name string) ([]byte, error) {
+	s.reads[name]++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.Source.Read(name)
+}
+
+func testFS() *FSSource {
+	return &FSSource{fsys: fstest.MapFS{
+		"vail.json":      {Data: []byte(`{"id": 13, "name": "Vail"}`)},
+		"eldora.json":    {Data: []byte(`{"id": 6, "name": "Eldora"}`)},
+		"broken.json":    {Data: []byte(`{"id": `)},
+		"README.md":      {Data: []byte("not a config")},
+		"family/vr.json": {Data: []byte(`{}`)},
+	}}
+}
+
+func TestFSSource(t *testing.T) {
+	source := testFS()
+
+	names, err := source.Names()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"broken", "eldora", "vail"}, names)
+
+	config, err := Load(source, "vail")
+	assert.NoError(t, err)
+	assert.Equal(t, 13, config.ID)
+	assert.Equal(t, "Vail", config.Name)
+
+	_, err = Load(source, "broken")
+	assert.ErrorContains(t, err, "failed to parse config broken")
+
+	_, err = Load(source, "aspen")
+	assert.ErrorContains(t, err, "failed to read config aspen")
+}
+
+func TestEmbeddedSourceMatchesConfigDir(t *testing.T) {
+	embedded, err := NewEmbeddedSource().Names()
+	assert.NoError(t, err)
+	dir, err := NewDirSource("../../config").Names()
+	assert.NoError(t, err)
+
+	assert.NotEmpty(t, embedded)
+	assert.Equal(t, dir, embedded)
+}
+
+func TestCachedSource(t *testing.T) {
+	counting := &countingSource{Source: testFS(), reads: map[string]int{}}
+	cached := NewCachedSource(counting, time.Minute)
+	now := time.Date(2024, 1, 10, 6, 0, 0, 0, time.UTC)
+	cached.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, err := cached.Names()
+		assert.NoError(t, err)
+		_, err = Load(cached, "vail")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, counting.names)
+	assert.Equal(t, 1, counting.reads["vail"])
+
+	now = now.Add(time.Minute)
+	_, err := Load(cached, "vail")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, counting.reads["vail"], "expired configs are read again")
+}
+
+func TestCachedSourceDoesNotCacheErrors(t *testing.T) {
+	counting := &countingSource{Source: testFS(), reads: map[string]int{}, err: errors.New("bucket unavailable")}
+	cached := NewCachedSource(counting, time.Minute)
+
+	_, err := cached.Read("vail")
+	assert.Error(t, err)
+
+	counting.err = nil
+	_, err = cached.Read("vail")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, counting.reads["vail"])
+}
+
+func TestNewSource(t *testing.T) {
+	t.Setenv("CONFIG_SOURCE", SourceDir)
+	t.Setenv("CONFIG_DIR", "../../config")
+	source, err := NewSource()
+	assert.NoError(t, err)
+	config, err := Load(source, "vail")
+	assert.NoError(t, err)
+	assert.Equal(t, "Vail", config.Name)
+
+	t.Setenv("CONFIG_SOURCE", "ftp")
+	_, err = NewSource()
+	assert.Error(t, err)
+}
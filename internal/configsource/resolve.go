@@ -0,0 +1,81 @@
+package configsource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// FamilyDir holds the templates shared by resorts on the same site, next to
+// the resort configs, e.g. "families/vail-resorts.json"
+const FamilyDir = "families"
+
+// Resolve returns a resort's config with the family template named by its
+// "extends" key merged underneath it. Objects are merged key by key, with
+// the resort's values winning; any other value, including arrays and
+// selector lists, replaces the template's outright. Templates can extend
+// other templates
+func Resolve(source Source, name string) ([]byte, error) {
+	config, err := resolve(source, name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Selectors are full of '>', which shouldn't come back as \u003e
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(config); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+func resolve(source Source, name string, chain []string) (map[string]interface{}, error) {
+	for _, seen := range chain {
+		if seen == name {
+			return nil, fmt.Errorf("config %s extends itself: %s", chain[0], strings.Join(append(chain, name), " -> "))
+		}
+	}
+
+	data, err := source.Read(name)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]interface{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", name, err)
+	}
+
+	extends, ok := config["extends"]
+	if !ok {
+		return config, nil
+	}
+	delete(config, "extends")
+	family, ok := extends.(string)
+	if !ok || family == "" {
+		return nil, fmt.Errorf("config %s: extends must name a template in %s/", name, FamilyDir)
+	}
+
+	template, err := resolve(source, path.Join(FamilyDir, family), append(chain, name))
+	if err != nil {
+		return nil, err
+	}
+	return merge(template, config), nil
+}
+
+// Merges override into base, recursing into objects present in both
+func merge(base, override map[string]interface{}) map[string]interface{} {
+	for key, value := range override {
+		baseObject, baseIsObject := base[key].(map[string]interface{})
+		overrideObject, overrideIsObject := value.(map[string]interface{})
+		if baseIsObject && overrideIsObject {
+			base[key] = merge(baseObject, overrideObject)
+		} else {
+			base[key] = value
+		}
+	}
+	return base
+}
@@ -0,0 +1,87 @@
+package configsource
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func familySource() *FSSource {
+	return &FSSource{fsys: fstest.MapFS{
+		"families/base.json": {Data: []byte(`{
+			"season": {"timezone": "America/Denver"},
+			"separateURLs": true
+		}`)},
+		"families/vail-resorts.json": {Data: []byte(`{
+			"extends": "base",
+			"conditions": {
+				"conditionsSelector": ".snow_report__metrics",
+				"baseDepthSelector": ["li.base h5", "li.base"],
+				"snow24Selector": "li.snow24 h5"
+			},
+			"terrain": {"terrainSelector": ".terrain_summary", "countRuns": true}
+		}`)},
+		"keystone.json": {Data: []byte(`{
+			"id": 12,
+			"name": "Keystone",
+			"extends": "vail-resorts",
+			"season": {"closing": "2024-04-07"},
+			"conditions": {"baseDepthSelector": "li.keystone-base"},
+			"terrain": {"countRuns": false, "runsOpenSelector": "#runs"}
+		}`)},
+		"loop.json":            {Data: []byte(`{"extends": "a"}`)},
+		"families/a.json":      {Data: []byte(`{"extends": "b"}`)},
+		"families/b.json":      {Data: []byte(`{"extends": "a"}`)},
+		"missing-family.json":  {Data: []byte(`{"extends": "alterra"}`)},
+		"numeric-extends.json": {Data: []byte(`{"extends": 3}`)},
+	}}
+}
+
+func TestResolveMergesFamilyTemplates(t *testing.T) {
+	config, err := Load(familySource(), "keystone")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 12, config.ID)
+	assert.Equal(t, "America/Denver", config.Season.Timezone, "inherited through two templates")
+	assert.Equal(t, "2024-04-07", config.Season.Closing, "nested objects merge key by key")
+	assert.True(t, config.SeparateURLs)
+	assert.Equal(t, ".snow_report__metrics", config.Conditions.ConditionsSelector)
+	assert.Equal(t, "li.snow24 h5", config.Conditions.Snow24Selector.String())
+	assert.Equal(t, []string{"li.keystone-base"}, config.Conditions.BaseDepthSelector.Selectors, "selector lists are replaced, not merged")
+	assert.False(t, config.Terrain.CountRuns)
+	assert.Equal(t, "#runs", config.Terrain.RunsOpenSelector)
+	assert.Equal(t, ".terrain_summary", config.Terrain.TerrainSelector)
+}
+
+func TestResolveDropsExtends(t *testing.T) {
+	data, err := Resolve(familySource(), "keystone")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "extends")
+	assert.Contains(t, string(data), `"li.snow24 h5"`)
+}
+
+func TestResolveErrors(t *testing.T) {
+	source := familySource()
+
+	_, err := Resolve(source, "loop")
+	assert.EqualError(t, err, "config loop extends itself: loop -> families/a -> families/b -> families/a")
+
+	_, err = Resolve(source, "missing-family")
+	assert.ErrorContains(t, err, "failed to read config families/alterra")
+
+	_, err = Resolve(source, "numeric-extends")
+	assert.EqualError(t, err, "config numeric-extends: extends must name a template in families/")
+}
+
+func TestEveryFamilyMemberResolves(t *testing.T) {
+	source := NewEmbeddedSource()
+	names, err := source.Names()
+	assert.NoError(t, err)
+
+	for _, name := range names {
+		config, err := Load(source, name)
+		assert.NoError(t, err, name)
+		assert.NotEmpty(t, config.Conditions.ConditionsSelector, name)
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/matcornic/hermes/v2"
 	"github.com/resend/resend-go/v2"
 )
 
+const stormTotalKey = "Storm Total"
+
 var h = hermes.Hermes{
 	Product: hermes.Product{
 		Name:      "The PowderHound team",
@@ -21,11 +24,24 @@ var h = hermes.Hermes{
 func BuildAlertEmail(emailData []EmailData, title, intro, key string) string {
 	var tableData [][]hermes.Entry
 
+	// Storm totals only get a column when one of the storms has grown past
+	// the fresh snow, otherwise they'd repeat it
+	showStormTotals := false
 	for _, data := range emailData {
-		tableData = append(tableData, []hermes.Entry{
+		if data.StormTotal > data.Snowfall {
+			showStormTotals = true
+		}
+	}
+
+	for _, data := range emailData {
+		row := []hermes.Entry{
 			{Key: "Location", Value: data.Location},
-			{Key: key, Value: fmt.Sprintf("%v\"", data.Snowfall)},
-		})
+			{Key: key, Value: formatInches(data.Snowfall)},
+		}
+		if showStormTotals {
+			row = append(row, hermes.Entry{Key: stormTotalKey, Value: formatStormTotal(data)})
+		}
+		tableData = append(tableData, row)
 	}
 
 	email := hermes.Email{
@@ -42,7 +58,8 @@ func BuildAlertEmail(emailData []EmailData, title, intro, key string) string {
 						key: "40%",
 					},
 					CustomAlignment: map[string]string{
-						key: "right",
+						key:           "right",
+						stormTotalKey: "right",
 					},
 				},
 			},
@@ -66,6 +83,18 @@ func BuildAlertEmail(emailData []EmailData, title, intro, key string) string {
 	return emailBody
 }
 
+func formatStormTotal(data EmailData) string {
+	if data.StormTotal == 0 {
+		return "-"
+	}
+	return formatInches(data.StormTotal)
+}
+
+// Formats inches to a tenth, without the decimal for whole inches
+func formatInches(inches float64) string {
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", inches), ".0") + "\""
+}
+
 func BuildForecastAlertEmail(emailData []EmailData) string {
 	return BuildAlertEmail(emailData, "Upcoming Snowfall", "A forecast alert has been triggered for the following locations.", "Next 24 Hours")
 }
@@ -74,6 +103,59 @@ func BuildOvernightAlertEmail(emailData []EmailData) string {
 	return BuildAlertEmail(emailData, "Snowfall Alert", "The following locations have received fresh snowfall.", "Fresh Snow")
 }
 
+func BuildAvalancheAlertEmail(alerts []AvalancheAlertData) string {
+	var tableData [][]hermes.Entry
+	var summaries []hermes.Entry
+	var actions []hermes.Action
+	for _, alert := range alerts {
+		danger := alert.Rating
+		if alert.PreviousRating != "" {
+			danger = fmt.Sprintf("%s (up from %s)", alert.Rating, alert.PreviousRating)
+		}
+		tableData = append(tableData, []hermes.Entry{
+			{Key: "Location", Value: alert.Location},
+			{Key: "Danger", Value: danger},
+			{Key: "Above Treeline", Value: alert.AboveTreeline},
+			{Key: "Near Treeline", Value: alert.NearTreeline},
+			{Key: "Below Treeline", Value: alert.BelowTreeline},
+		})
+		if alert.Summary != "" {
+			summaries = append(summaries, hermes.Entry{Key: alert.Location, Value: alert.Summary})
+		}
+		if alert.ForecastURL != "" {
+			actions = append(actions, hermes.Action{
+				Instructions: fmt.Sprintf("Read the full %s forecast before heading out:", alert.Location),
+				Button: hermes.Button{
+					Text: "View Avalanche Forecast",
+					Link: alert.ForecastURL,
+				},
+			})
+		}
+	}
+
+	email := hermes.Email{
+		Body: hermes.Body{
+			Title:     "Avalanche Danger Alert",
+			Signature: "Stay safe",
+			Intros: []string{
+				"Avalanche danger is elevated or rising at the following backcountry locations.",
+			},
+			Table: hermes.Table{
+				Data: tableData,
+			},
+			Dictionary: summaries,
+			Actions:    actions,
+		},
+	}
+
+	emailBody, err := h.GenerateHTML(email)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return emailBody
+}
+
 func (s *ResendService) SendEmail(subject string, body string, to string) error {
 	params := &resend.SendEmailRequest{
 		From:    "PowderHound <alerts@powderhound.io>",
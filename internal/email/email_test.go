@@ -34,3 +34,56 @@ func TestSendEmail(t *testing.T) {
 		assert.Nil(t, err, "Expected SendEmail to return no error")
 	})
 }
+
+func TestBuildOvernightAlertEmailStormTotals(t *testing.T) {
+	t.Run("adds a storm total column when a storm has grown past the fresh snow", func(t *testing.T) {
+		body := BuildOvernightAlertEmail([]EmailData{
+			{Location: "Breckenridge", Snowfall: 6, StormTotal: 22},
+			{Location: "Vail", Snowfall: 2},
+		})
+		assert.Contains(t, body, "Storm Total")
+		assert.Contains(t, body, "22&#34;")
+	})
+
+	t.Run("leaves the column out when storm totals match the fresh snow", func(t *testing.T) {
+		body := BuildOvernightAlertEmail([]EmailData{
+			{Location: "Breckenridge", Snowfall: 6, StormTotal: 6},
+		})
+		assert.NotContains(t, body, "Storm Total")
+	})
+
+	t.Run("formats inches to a tenth", func(t *testing.T) {
+		body := BuildOvernightAlertEmail([]EmailData{
+			{Location: "Whistler", Snowfall: 45 / 2.54, StormTotal: 24.25},
+		})
+		assert.Contains(t, body, "17.7&#34;")
+		assert.Contains(t, body, "24.2&#34;")
+		assert.NotContains(t, body, "17.71")
+	})
+}
+
+func TestBuildAvalancheAlertEmail(t *testing.T) {
+	body := BuildAvalancheAlertEmail([]AvalancheAlertData{
+		{
+			Location:       "Berthoud Pass",
+			Rating:         "High",
+			Level:          4,
+			PreviousRating: "Considerable",
+			AboveTreeline:  "4 - High",
+			NearTreeline:   "3 - Considerable",
+			BelowTreeline:  "2 - Moderate",
+			Summary:        "Avoid wind-loaded slopes near and above treeline.",
+			ForecastURL:    "https://avalanche.state.co.us/?lat=39.798000&lng=-105.777000",
+		},
+		{Location: "Snoqualmie Pass", Rating: "Considerable", Level: 3, AboveTreeline: "-", NearTreeline: "-", BelowTreeline: "-"},
+	})
+
+	assert.Contains(t, body, "Avalanche Danger Alert")
+	assert.Contains(t, body, "High (up from Considerable)")
+	assert.Contains(t, body, "Above Treeline")
+	assert.Contains(t, body, "3 - Considerable")
+	assert.Contains(t, body, "Avoid wind-loaded slopes near and above treeline.")
+	assert.Contains(t, body, "https://avalanche.state.co.us/?lat=39.798000&amp;lng=-105.777000")
+	assert.Contains(t, body, "Snoqualmie Pass")
+	assert.NotContains(t, body, "Read the full Snoqualmie Pass forecast", "no button without a forecast link")
+}
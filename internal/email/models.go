@@ -4,7 +4,25 @@ import "github.com/resend/resend-go/v2"
 
 type EmailData struct {
 	Location string
-	Snowfall int
+	// Inches, with decimals
+	Snowfall float64
+	// Total of the storm the snowfall belongs to, 0 when there isn't one
+	StormTotal float64
+}
+
+type AvalancheAlertData struct {
+	Location string
+	// Overall danger, e.g. "Considerable"
+	Rating string
+	Level  int
+	// Rating of the previous forecast when the danger rose, empty otherwise
+	PreviousRating string
+	// Today's rating in each elevation band, e.g. "3 - Considerable"
+	AboveTreeline string
+	NearTreeline  string
+	BelowTreeline string
+	Summary       string
+	ForecastURL   string
 }
 
 type ResendService struct {
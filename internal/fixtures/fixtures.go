@@ -0,0 +1,168 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/chromedp/chromedp"
+)
+
+const ManifestFileName = "manifest.json"
+
+// Serializes the current DOM with scripts, stylesheets and frames removed so
+// the snapshot renders the same way every time it is replayed, without
+// reaching out to the resort's servers
+const snapshotScript = `(() => {
+	const root = document.documentElement.cloneNode(true);
+	root.querySelectorAll('script, noscript, link, iframe').forEach((el) => el.remove());
+	return '<!DOCTYPE html>\n' + root.outerHTML;
+})()`
+
+// Page is a single DOM snapshot captured while scraping a resort
+type Page struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+	File  string `json:"file"`
+}
+
+// Manifest lists the snapshots recorded for a resort in the order they were captured
+type Manifest struct {
+	Mountain   string    `json:"mountain"`
+	RecordedAt time.Time `json:"recordedAt"`
+	Pages      []Page    `json:"pages"`
+}
+
+func ReadManifest(dir string) (Manifest, error) {
+	var manifest Manifest
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read fixture manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse fixture manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// Recorder saves every page a scrape loads into a fixture directory
+type Recorder struct {
+	dir      string
+	manifest Manifest
+}
+
+func NewRecorder(dir string, mountain string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create fixture directory %s: %w", dir, err)
+	}
+	return &Recorder{
+		dir:      dir,
+		manifest: Manifest{Mountain: mountain, RecordedAt: time.Now()},
+	}, nil
+}
+
+// Snapshot captures the DOM of the page currently loaded in ctx under the given label
+func (r *Recorder) Snapshot(ctx context.Context, label string) error {
+	var url, html string
+	err := chromedp.Run(ctx,
+		chromedp.Location(&url),
+		chromedp.Evaluate(snapshotScript, &html),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot %s page: %w", label, err)
+	}
+
+	fileName := fmt.Sprintf("%02d-%s.html", len(r.manifest.Pages)+1, label)
+	if err := os.WriteFile(filepath.Join(r.dir, fileName), []byte(html), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", fileName, err)
+	}
+
+	r.manifest.Pages = append(r.manifest.Pages, Page{Label: label, URL: url, File: fileName})
+	log.Printf("Recorded %s page for %s: %s", label, r.manifest.Mountain, fileName)
+	return r.writeManifest()
+}
+
+func (r *Recorder) writeManifest() error {
+	data, err := json.MarshalIndent(r.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.dir, ManifestFileName), data, 0o644)
+}
+
+// ReplayServer serves a recorded fixture directory from a local HTTP server.
+//
+// When the same URL was captured more than once (e.g. before and after a click),
+// the last snapshot is served since it holds the most complete DOM
+type ReplayServer struct {
+	*httptest.Server
+	paths map[string]string
+}
+
+func NewReplayServer(dir string) (*ReplayServer, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+	paths := map[string]string{}
+	for _, page := range manifest.Pages {
+		path, ok := paths[page.URL]
+		if !ok {
+			path = fmt.Sprintf("/pages/%d", len(paths)+1)
+			paths[page.URL] = path
+		}
+		files[path] = filepath.Join(dir, page.File)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		file, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		// Content type follows the file extension, so JSON feeds replay as JSON
+		http.ServeFile(w, r, file)
+	})
+
+	return &ReplayServer{Server: httptest.NewServer(mux), paths: paths}, nil
+}
+
+// ReplayURL maps a recorded URL to its local address. URLs that were never
+// recorded map to a path that 404s so a replay can never hit the live site
+func (s *ReplayServer) ReplayURL(url string) string {
+	if path, ok := s.paths[url]; ok {
+		return s.URL + path
+	}
+	return s.URL + "/not-recorded"
+}
+
+// RewriteConfig points the config's conditions and terrain URLs and any navigate steps at the replay server
+func (s *ReplayServer) RewriteConfig(config supabase.ScrapingConfig) supabase.ScrapingConfig {
+	config.ConditionsURL = s.ReplayURL(config.ConditionsURL)
+	if config.TerrainURL != "" {
+		config.TerrainURL = s.ReplayURL(config.TerrainURL)
+	}
+	if len(config.Steps) > 0 {
+		// Copy so the caller's steps still point at the live site
+		steps := make([]supabase.Step, len(config.Steps))
+		for i, step := range config.Steps {
+			if step.Action == supabase.StepNavigate {
+				step.URL = s.ReplayURL(step.URL)
+			}
+			steps[i] = step
+		}
+		config.Steps = steps
+	}
+	return config
+}
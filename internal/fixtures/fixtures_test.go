@@ -0,0 +1,87 @@
+package fixtures
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFixture(t *testing.T, dir string, manifest string, files map[string]string) {
+	t.Helper()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFileName), []byte(manifest), 0o644))
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestReplayServer(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, `{
+		"mountain": "Test Mountain",
+		"pages": [
+			{"label": "conditions", "url": "https://example.com/snow", "file": "01-conditions.html"},
+			{"label": "click", "url": "https://example.com/snow", "file": "02-click.html"},
+			{"label": "terrain", "url": "https://example.com/terrain", "file": "03-terrain.html"}
+		]
+	}`, map[string]string{
+		"01-conditions.html": "before click",
+		"02-click.html":      "after click",
+		"03-terrain.html":    "terrain",
+	})
+
+	server, err := NewReplayServer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	t.Run("serves the last snapshot recorded for a URL", func(t *testing.T) {
+		status, body := get(t, server.ReplayURL("https://example.com/snow"))
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "after click", body)
+	})
+
+	t.Run("returns 404 for URLs that were never recorded", func(t *testing.T) {
+		status, _ := get(t, server.ReplayURL("https://example.com/unknown"))
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("rewrites config URLs to the replay server", func(t *testing.T) {
+		config := server.RewriteConfig(supabase.ScrapingConfig{
+			ConditionsURL: "https://example.com/snow",
+			TerrainURL:    "https://example.com/terrain",
+		})
+		_, body := get(t, config.TerrainURL)
+		assert.Equal(t, "terrain", body)
+		assert.Equal(t, server.ReplayURL("https://example.com/snow"), config.ConditionsURL)
+	})
+
+	t.Run("rewrites navigate steps to the replay server", func(t *testing.T) {
+		steps := []supabase.Step{
+			{Action: supabase.StepNavigate, Before: supabase.StageTerrain, URL: "https://example.com/terrain"},
+			{Action: supabase.StepClick, Selector: "button.lifts"},
+		}
+		config := server.RewriteConfig(supabase.ScrapingConfig{ConditionsURL: "https://example.com/snow", Steps: steps})
+		_, body := get(t, config.Steps[0].URL)
+		assert.Equal(t, "terrain", body)
+		assert.Equal(t, steps[1], config.Steps[1])
+		assert.Equal(t, "https://example.com/terrain", steps[0].URL, "original steps are left untouched")
+	})
+}
@@ -0,0 +1,254 @@
+package history
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+)
+
+// Hourly snapshots are kept for this long before being downsampled to one per day
+const HourlyRetention = 14 * 24 * time.Hour
+
+// Fields that can be queried, named after their resort_conditions columns
+const (
+	FieldBaseDepth    = "base_depth"
+	FieldSnowPast24h  = "snow_past_24h"
+	FieldSnowPast48h  = "snow_past_48h"
+	FieldSnowPastWeek = "snow_past_week"
+	FieldSnowTotal    = "snow_total"
+	FieldLiftsOpen    = "lifts_open"
+	FieldRunsOpen     = "runs_open"
+)
+
+// FromConditions converts a resort_conditions row into a snapshot recorded at the given time
+func FromConditions(data map[string]interface{}, recordedAt time.Time) supabase.ConditionsSnapshot {
+	intValue := func(key string) int {
+		value, _ := data[key].(int)
+		return value
+	}
+	// Snow measurements are inches with decimals
+	inchesValue := func(key string) float64 {
+		value, _ := data[key].(float64)
+		return value
+	}
+	mountainID, _ := data["mountain_id"].(int)
+	snowType, _ := data["snow_type"].(string)
+
+	return supabase.ConditionsSnapshot{
+		MountainID:   mountainID,
+		RecordedAt:   recordedAt.UTC(),
+		Resolution:   supabase.ResolutionHourly,
+		BaseDepth:    inchesValue(FieldBaseDepth),
+		SnowPast24h:  inchesValue(FieldSnowPast24h),
+		SnowPast48h:  inchesValue(FieldSnowPast48h),
+		SnowPastWeek: inchesValue(FieldSnowPastWeek),
+		SnowTotal:    inchesValue(FieldSnowTotal),
+		LiftsOpen:    intValue(FieldLiftsOpen),
+		RunsOpen:     intValue(FieldRunsOpen),
+		SnowType:     snowType,
+	}
+}
+
+// SameValues reports whether two snapshots hold the same conditions,
+// ignoring when and how they were stored
+func SameValues(a, b supabase.ConditionsSnapshot) bool {
+	a.ID, b.ID = 0, 0
+	a.RecordedAt, b.RecordedAt = time.Time{}, time.Time{}
+	a.Resolution, b.Resolution = "", ""
+	return a == b
+}
+
+// Value returns the named field of a snapshot, lift and run counts included
+// as whole numbers
+func Value(snapshot supabase.ConditionsSnapshot, field string) (float64, error) {
+	switch field {
+	case FieldBaseDepth:
+		return snapshot.BaseDepth, nil
+	case FieldSnowPast24h:
+		return snapshot.SnowPast24h, nil
+	case FieldSnowPast48h:
+		return snapshot.SnowPast48h, nil
+	case FieldSnowPastWeek:
+		return snapshot.SnowPastWeek, nil
+	case FieldSnowTotal:
+		return snapshot.SnowTotal, nil
+	case FieldLiftsOpen:
+		return float64(snapshot.LiftsOpen), nil
+	case FieldRunsOpen:
+		return float64(snapshot.RunsOpen), nil
+	}
+	return 0, fmt.Errorf("unknown conditions field %q", field)
+}
+
+// Record appends a successful scrape to the resort's history. Nothing is
+// written when the conditions haven't changed since the latest snapshot, so
+// a snapshot holds until the next one is recorded. Reports whether a
+// snapshot was written
+func Record(client supabase.SupabaseClient, data map[string]interface{}, now time.Time) (bool, error) {
+	snapshot := FromConditions(data, now)
+
+	latest, err := client.GetConditionsSnapshotAt(snapshot.MountainID, now)
+	if err != nil {
+		return false, err
+	}
+	if latest != nil && SameValues(*latest, snapshot) {
+		log.Printf("Conditions for mountain %d unchanged since %s, skipping history", snapshot.MountainID, latest.RecordedAt)
+		return false, nil
+	}
+
+	if err := client.InsertConditionsSnapshot(snapshot); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ValueAt returns a field's value at time t, taken from the latest snapshot
+// recorded at or before t. Reports false when the history starts after t
+func ValueAt(client supabase.SupabaseClient, mountainID int, field string, t time.Time) (float64, bool, error) {
+	snapshot, err := client.GetConditionsSnapshotAt(mountainID, t)
+	if err != nil || snapshot == nil {
+		return 0, false, err
+	}
+	value, err := Value(*snapshot, field)
+	if err != nil {
+		return 0, false, err
+	}
+	return value, true, nil
+}
+
+// DailyRange is the lowest and highest value a field held on one day
+type DailyRange struct {
+	Day time.Time
+	Min float64
+	Max float64
+}
+
+// DailyMinMax returns a field's daily minimum and maximum for each day from
+// from to to inclusive, with days starting at midnight in loc. Days before the
+// history starts are left out
+func DailyMinMax(client supabase.SupabaseClient, mountainID int, field string, from, to time.Time, loc *time.Location) ([]DailyRange, error) {
+	start := startOfDay(from, loc)
+	end := startOfDay(to, loc).AddDate(0, 0, 1)
+
+	// The snapshot in effect when the first day starts, since unchanged
+	// conditions aren't written again
+	initial, err := client.GetConditionsSnapshotAt(mountainID, start)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := client.GetConditionsSnapshots(mountainID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if initial != nil {
+		snapshots = append([]supabase.ConditionsSnapshot{*initial}, snapshots...)
+	}
+	return dailyMinMax(snapshots, field, start, end, loc)
+}
+
+// Computes the daily ranges in [start, end) from snapshots sorted oldest
+// first. Each snapshot's value holds until the next one
+func dailyMinMax(snapshots []supabase.ConditionsSnapshot, field string, start, end time.Time, loc *time.Location) ([]DailyRange, error) {
+	var ranges []DailyRange
+	next := 0
+	var current *float64
+
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1)
+
+		// Catch up to the value in effect at the start of the day
+		for next < len(snapshots) && !snapshots[next].RecordedAt.After(day) {
+			value, err := Value(snapshots[next], field)
+			if err != nil {
+				return nil, err
+			}
+			current = &value
+			next++
+		}
+
+		var dayRange *DailyRange
+		if current != nil {
+			dayRange = &DailyRange{Day: day, Min: *current, Max: *current}
+		}
+		for next < len(snapshots) && snapshots[next].RecordedAt.Before(dayEnd) {
+			value, err := Value(snapshots[next], field)
+			if err != nil {
+				return nil, err
+			}
+			if dayRange == nil {
+				dayRange = &DailyRange{Day: day, Min: value, Max: value}
+			}
+			dayRange.Min = min(dayRange.Min, value)
+			dayRange.Max = max(dayRange.Max, value)
+			current = &value
+			next++
+		}
+
+		if dayRange != nil {
+			ranges = append(ranges, *dayRange)
+		}
+	}
+	return ranges, nil
+}
+
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// Downsample picks, for each mountain and UTC day, the last hourly snapshot
+// to keep as that day's daily snapshot. The rest of the day's snapshots are
+// returned for removal
+func Downsample(snapshots []supabase.ConditionsSnapshot) (keep, remove []int) {
+	type mountainDay struct {
+		mountainID int
+		day        string
+	}
+	last := map[mountainDay]supabase.ConditionsSnapshot{}
+	var days []mountainDay
+
+	for _, snapshot := range snapshots {
+		key := mountainDay{snapshot.MountainID, snapshot.RecordedAt.UTC().Format("2006-01-02")}
+		kept, ok := last[key]
+		if !ok {
+			days = append(days, key)
+			last[key] = snapshot
+			continue
+		}
+		if snapshot.RecordedAt.After(kept.RecordedAt) {
+			remove = append(remove, kept.ID)
+			last[key] = snapshot
+		} else {
+			remove = append(remove, snapshot.ID)
+		}
+	}
+
+	for _, day := range days {
+		keep = append(keep, last[day].ID)
+	}
+	sort.Ints(keep)
+	sort.Ints(remove)
+	return keep, remove
+}
+
+// Prune downsamples every hourly snapshot older than HourlyRetention. The
+// cutoff is moved back to the start of its UTC day, so a day is only
+// downsampled once all of its snapshots are past retention and keeps a single
+// daily snapshot across runs
+func Prune(client supabase.SupabaseClient, now time.Time) error {
+	cutoff := startOfDay(now.Add(-HourlyRetention), time.UTC)
+	snapshots, err := client.GetHourlyConditionsSnapshots(cutoff)
+	if err != nil {
+		return err
+	}
+
+	keep, remove := Downsample(snapshots)
+	log.Printf("Downsampling %d hourly snapshots before %s: keeping %d daily", len(snapshots), cutoff.Format(time.RFC3339), len(keep))
+	if err := client.MarkConditionsSnapshotsDaily(keep); err != nil {
+		return err
+	}
+	return client.DeleteConditionsSnapshots(remove)
+}
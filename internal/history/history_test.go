@@ -0,0 +1,256 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// In-memory conditions_history table. Methods the history package doesn't
+// use panic through the nil embedded client
+type fakeClient struct {
+	supabase.SupabaseClient
+	snapshots []supabase.ConditionsSnapshot
+	nextID    int
+	marked    []int
+	deleted   []int
+}
+
+func (f *fakeClient) InsertConditionsSnapshot(snapshot supabase.ConditionsSnapshot) error {
+	f.nextID++
+	snapshot.ID = f.nextID
+	f.snapshots = append(f.snapshots, snapshot)
+	return nil
+}
+
+func (f *fakeClient) GetConditionsSnapshotAt(mountainID int, at time.Time) (*supabase.ConditionsSnapshot, error) {
+	var latest *supabase.ConditionsSnapshot
+	for i, snapshot := range f.snapshots {
+		if snapshot.MountainID != mountainID || snapshot.RecordedAt.After(at) {
+			continue
+		}
+		if latest == nil || snapshot.RecordedAt.After(latest.RecordedAt) {
+			latest = &f.snapshots[i]
+		}
+	}
+	return latest, nil
+}
+
+func (f *fakeClient) GetConditionsSnapshots(mountainID int, from, to time.Time) ([]supabase.ConditionsSnapshot, error) {
+	var snapshots []supabase.ConditionsSnapshot
+	for _, snapshot := range f.snapshots {
+		if snapshot.MountainID == mountainID && snapshot.RecordedAt.After(from) && snapshot.RecordedAt.Before(to) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
+func (f *fakeClient) GetHourlyConditionsSnapshots(before time.Time) ([]supabase.ConditionsSnapshot, error) {
+	var snapshots []supabase.ConditionsSnapshot
+	for _, snapshot := range f.snapshots {
+		if snapshot.Resolution == supabase.ResolutionHourly && snapshot.RecordedAt.Before(before) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
+func (f *fakeClient) MarkConditionsSnapshotsDaily(ids []int) error {
+	f.marked = append(f.marked, ids...)
+	for _, id := range ids {
+		for i := range f.snapshots {
+			if f.snapshots[i].ID == id {
+				f.snapshots[i].Resolution = supabase.ResolutionDaily
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeClient) DeleteConditionsSnapshots(ids []int) error {
+	f.deleted = append(f.deleted, ids...)
+	deleted := map[int]bool{}
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	var kept []supabase.ConditionsSnapshot
+	for _, snapshot := range f.snapshots {
+		if !deleted[snapshot.ID] {
+			kept = append(kept, snapshot)
+		}
+	}
+	f.snapshots = kept
+	return nil
+}
+
+var start = time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+func conditions(baseDepth, snow24 float64) map[string]interface{} {
+	return map[string]interface{}{
+		"mountain_id":   7,
+		"display_name":  "Steamboat",
+		"base_depth":    baseDepth,
+		"snow_past_24h": snow24,
+		"snow_past_48h": snow24,
+		"lifts_open":    10,
+		"runs_open":     100,
+		"snow_type":     "Powder",
+		"updated_at":    start,
+	}
+}
+
+func TestRecordSkipsUnchangedConditions(t *testing.T) {
+	client := &fakeClient{}
+
+	written, err := Record(client, conditions(40, 2), start)
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	written, err = Record(client, conditions(40, 2), start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, written)
+
+	written, err = Record(client, conditions(42, 4), start.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	assert.Len(t, client.snapshots, 2)
+	assert.Equal(t, supabase.ResolutionHourly, client.snapshots[0].Resolution)
+	assert.Equal(t, "Powder", client.snapshots[0].SnowType)
+	assert.Equal(t, 42.0, client.snapshots[1].BaseDepth)
+}
+
+func TestValueAt(t *testing.T) {
+	client := &fakeClient{}
+	Record(client, conditions(40, 2), start)
+	Record(client, conditions(46, 6), start.Add(6*time.Hour))
+
+	_, ok, err := ValueAt(client, 7, FieldBaseDepth, start.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	value, ok, err := ValueAt(client, 7, FieldBaseDepth, start.Add(3*time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 40.0, value)
+
+	value, _, _ = ValueAt(client, 7, FieldSnowPast24h, start.Add(6*time.Hour))
+	assert.Equal(t, 6.0, value)
+
+	_, _, err = ValueAt(client, 7, "depth", start)
+	assert.Error(t, err)
+}
+
+func TestDailyMinMax(t *testing.T) {
+	client := &fakeClient{}
+	Record(client, conditions(40, 0), start.Add(-2*time.Hour))
+	Record(client, conditions(44, 4), start.Add(8*time.Hour))
+	Record(client, conditions(38, 4), start.Add(20*time.Hour))
+	// Nothing recorded on the 11th, the 20:00 value holds all day
+	Record(client, conditions(50, 12), start.Add(54*time.Hour))
+
+	ranges, err := DailyMinMax(client, 7, FieldBaseDepth, start, start.Add(60*time.Hour), time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []DailyRange{
+		{Day: start, Min: 38, Max: 44},
+		{Day: start.AddDate(0, 0, 1), Min: 38, Max: 38},
+		{Day: start.AddDate(0, 0, 2), Min: 38, Max: 50},
+	}, ranges)
+}
+
+func TestDailyMinMaxSkipsDaysBeforeHistory(t *testing.T) {
+	client := &fakeClient{}
+	Record(client, conditions(40, 0), start.Add(30*time.Hour))
+
+	ranges, err := DailyMinMax(client, 7, FieldBaseDepth, start, start.Add(30*time.Hour), time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, []DailyRange{
+		{Day: start.AddDate(0, 0, 1), Min: 40, Max: 40},
+	}, ranges)
+}
+
+func TestDailyMinMaxUsesLocation(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+	client := &fakeClient{}
+	// 03:00 UTC on the 10th is still the evening of the 9th in Denver
+	Record(client, conditions(40, 0), start.Add(3*time.Hour))
+	Record(client, conditions(48, 8), start.Add(12*time.Hour))
+
+	ranges, err := DailyMinMax(client, 7, FieldBaseDepth, start.Add(-24*time.Hour), start.Add(12*time.Hour), denver)
+	assert.NoError(t, err)
+	assert.Len(t, ranges, 2)
+	assert.Equal(t, 9, ranges[0].Day.Day())
+	assert.Equal(t, 40.0, ranges[0].Max)
+	assert.Equal(t, 40.0, ranges[1].Min)
+	assert.Equal(t, 48.0, ranges[1].Max)
+}
+
+func TestDownsample(t *testing.T) {
+	snapshots := []supabase.ConditionsSnapshot{
+		{ID: 1, MountainID: 7, RecordedAt: start.Add(time.Hour)},
+		{ID: 2, MountainID: 7, RecordedAt: start.Add(20 * time.Hour)},
+		{ID: 3, MountainID: 7, RecordedAt: start.Add(5 * time.Hour)},
+		{ID: 4, MountainID: 8, RecordedAt: start.Add(2 * time.Hour)},
+		{ID: 5, MountainID: 7, RecordedAt: start.Add(25 * time.Hour)},
+	}
+
+	keep, remove := Downsample(snapshots)
+	assert.Equal(t, []int{2, 4, 5}, keep)
+	assert.Equal(t, []int{1, 3}, remove)
+}
+
+func TestPruneOnlyDownsamplesPastRetention(t *testing.T) {
+	client := &fakeClient{}
+	now := start.Add(HourlyRetention + 48*time.Hour)
+	Record(client, conditions(40, 0), start.Add(time.Hour))
+	Record(client, conditions(42, 2), start.Add(2*time.Hour))
+	// Within the retention window, stays hourly
+	Record(client, conditions(44, 2), now.Add(-time.Hour))
+
+	assert.NoError(t, Prune(client, now))
+	assert.Equal(t, []int{2}, client.marked)
+	assert.Equal(t, []int{1}, client.deleted)
+}
+
+func TestPruneKeepsOneDailySnapshotAcrossRuns(t *testing.T) {
+	client := &fakeClient{}
+	Record(client, conditions(40, 0), start.Add(time.Hour))
+	Record(client, conditions(42, 2), start.Add(6*time.Hour))
+	Record(client, conditions(44, 2), start.Add(18*time.Hour))
+
+	// Retention ends at noon on the first day, which is only half past it
+	now := start.Add(HourlyRetention + 12*time.Hour)
+	assert.NoError(t, Prune(client, now))
+	assert.Empty(t, client.marked)
+
+	assert.NoError(t, Prune(client, now.Add(24*time.Hour)))
+	assert.Equal(t, []int{3}, client.marked)
+	assert.Equal(t, []int{1, 2}, client.deleted)
+	if assert.Len(t, client.snapshots, 1) {
+		assert.Equal(t, supabase.ResolutionDaily, client.snapshots[0].Resolution)
+	}
+}
+
+func TestFromConditionsKeepsDecimals(t *testing.T) {
+	snapshot := FromConditions(conditions(40.5, 1.5), start)
+	assert.Equal(t, 40.5, snapshot.BaseDepth)
+	assert.Equal(t, 1.5, snapshot.SnowPast24h)
+}
+
+func TestFromConditionsDefaultsMissingFields(t *testing.T) {
+	data := conditions(40, 2)
+	delete(data, "snow_type")
+
+	snapshot := FromConditions(data, start)
+	assert.Equal(t, 7, snapshot.MountainID)
+	assert.Equal(t, 0.0, snapshot.SnowTotal)
+	assert.Equal(t, "", snapshot.SnowType)
+	assert.True(t, SameValues(snapshot, FromConditions(data, start.Add(time.Hour))))
+}
@@ -2,38 +2,84 @@ package queue
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"sort"
 	"time"
 
+	"powderhoundgo/internal/avalanche"
+	"powderhoundgo/internal/configsource"
 	"powderhoundgo/internal/email"
+	"powderhoundgo/internal/scraping"
+	"powderhoundgo/internal/season"
 	"powderhoundgo/internal/supabase"
 	"powderhoundgo/internal/tasks"
 
 	"github.com/hibiken/asynq"
 )
 
+// A pre-season scrape's task ID is per local day and only taken from
+// season.DailyScrapeHour, so a day of retention covers the rest of that day
+const preSeasonRetention = 24 * time.Hour
+
 func QueueResortWebScrapeTasks(client *asynq.Client, supabase supabase.SupabaseClient) {
-	mountainNames := supabase.GetAllMountainObjectNames()
+	source, err := configsource.Default()
+	if err != nil {
+		log.Printf("[*] Error opening config source: %v", err)
+		return
+	}
+	mountainNames, err := source.Names()
+	if err != nil {
+		log.Printf("[*] Error listing resort configs: %v", err)
+		return
+	}
+
+	now := time.Now()
 	for _, mountain := range mountainNames {
-		if isResortClosed(mountain, supabase) {
-			log.Printf("[*] Resort %s is closed - skipping job", mountain)
-		} else {
-			payload, err := json.Marshal(tasks.ResortWebScrapePayload{MountainName: mountain})
-			if err != nil {
-				log.Fatal(err)
+		calendar, err := resortCalendar(source, mountain)
+		if err != nil {
+			log.Printf("[*] Error loading config for %s - skipping job: %v", mountain, err)
+			continue
+		}
+		status := calendar.StatusAt(now)
+
+		opts := []asynq.Option{asynq.MaxRetry(3), asynq.Timeout(5 * time.Minute)}
+		switch status.Mode {
+		case season.ModeClosed:
+			log.Printf("[*] Resort %s is closed (%s) - skipping job", mountain, status.Reason)
+			continue
+		case season.ModePreSeason:
+			key := calendar.DailyScrapeKey(now)
+			if key == "" {
+				continue
 			}
+			// Later runs the same day conflict with this task's ID and are skipped.
+			// The ID is only held while asynq keeps the completed task, so it's
+			// kept for the rest of the resort's day rather than buildTask's retention
+			opts = append(opts,
+				asynq.TaskID(fmt.Sprintf("%s:%s:%s", tasks.TypeResortWebScrapingJob, mountain, key)),
+				asynq.Retention(preSeasonRetention),
+			)
+		}
 
-			task := buildTask(tasks.TypeResortWebScrapingJob, payload)
+		payload, err := json.Marshal(tasks.ResortWebScrapePayload{MountainName: mountain})
+		if err != nil {
+			log.Fatal(err)
+		}
 
-			info, err := client.Enqueue(task, asynq.MaxRetry(3), asynq.Timeout(5*time.Minute))
+		task := buildTask(tasks.TypeResortWebScrapingJob, payload)
 
-			if err != nil {
-				log.Printf("[*] Error enqueuing task: %v", err)
-			}
-			log.Printf("[*] Enqueued task: %v", info)
+		info, err := client.Enqueue(task, opts...)
+
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			continue
 		}
+		if err != nil {
+			log.Printf("[*] Error enqueuing task: %v", err)
+		}
+		log.Printf("[*] Enqueued task: %v", info)
 	}
 }
 
@@ -44,11 +90,37 @@ func QueueAvalancheScrapingTasks(client *asynq.Client, supabaseClient supabase.S
 		return
 	}
 
+	// Without zones every mountain is scraped on its own
+	zones, err := scraping.LoadForecastZones()
+	if err != nil {
+		log.Printf("[*] Error loading avalanche forecast zones: %v", err)
+	}
+
+	var coordinates []scraping.MountainCoordinates
 	for _, mountain := range mountains {
+		coordinates = append(coordinates, scraping.MountainCoordinates{
+			MountainID:      mountain.MountainID,
+			Lat:             mountain.Lat,
+			Lon:             mountain.Lon,
+			AvalancheCenter: mountain.AvalancheCenter,
+		})
+	}
+
+	for _, group := range zones.Group(coordinates) {
+		// The forecast is fetched for the first mountain and saved for all of them
+		mountain := group.Mountains[0]
+		var mountainIDs []int
+		for _, m := range group.Mountains {
+			mountainIDs = append(mountainIDs, m.MountainID)
+		}
+
 		payload, err := json.Marshal(tasks.AvalancheScrapingPayload{
-			MountainID: mountain.MountainID,
-			Lat:        mountain.Lat,
-			Lon:        mountain.Lon,
+			MountainID:      mountain.MountainID,
+			Lat:             mountain.Lat,
+			Lon:             mountain.Lon,
+			AvalancheCenter: mountain.AvalancheCenter,
+			ZoneID:          group.ZoneID,
+			Mountains:       group.Mountains,
 		})
 		if err != nil {
 			log.Printf("[*] Error marshalling avalanche payload: %v", err)
@@ -61,17 +133,31 @@ func QueueAvalancheScrapingTasks(client *asynq.Client, supabaseClient supabase.S
 		if err != nil {
 			log.Printf("[*] Error enqueuing avalanche task: %v", err)
 		}
+		if group.ZoneID != "" {
+			log.Printf("[*] Enqueued avalanche task for zone %s, mountains %v: %v", group.ZoneID, mountainIDs, info)
+			continue
+		}
 		log.Printf("[*] Enqueued avalanche task for mountain %d: %v", mountain.MountainID, info)
 	}
 }
 
+func QueueHistoryPruneTask(client *asynq.Client) {
+	task := buildTask(tasks.TypeHistoryPruneJob, nil)
+
+	info, err := client.Enqueue(task, asynq.MaxRetry(3), asynq.Timeout(10*time.Minute))
+	if err != nil {
+		log.Printf("[*] Error enqueuing history prune task: %v", err)
+	}
+	log.Printf("[*] Enqueued history prune task: %v", info)
+}
+
 func QueueForecastAlertEmailTasks(client *asynq.Client, supabase supabase.SupabaseClient) {
 	userAlerts := supabase.GetUserForecastAlerts()
 
 	for _, user := range userAlerts {
 		var emailData []email.EmailData
 		for _, alert := range user.Alerts {
-			emailData = append(emailData, email.EmailData{Location: alert.Location, Snowfall: alert.Snowfall})
+			emailData = append(emailData, email.EmailData{Location: alert.Location, Snowfall: float64(alert.Snowfall)})
 		}
 
 		sort.Slice(emailData, func(i, j int) bool {
@@ -97,10 +183,17 @@ func QueueForecastAlertEmailTasks(client *asynq.Client, supabase supabase.Supaba
 func QueueOvernightAlertEmailTasks(client *asynq.Client, supabase supabase.SupabaseClient) {
 	userAlerts := supabase.GetUserOvernightAlerts()
 
+	// Users share mountains, each one's active storm is only looked up once
+	stormTotals := map[int]float64{}
 	for _, user := range userAlerts {
 		var emailData []email.EmailData
 		for _, alert := range user.Alerts {
-			emailData = append(emailData, email.EmailData{Location: alert.Location, Snowfall: alert.Snowfall})
+			stormTotal, checked := stormTotals[alert.MountainID]
+			if !checked {
+				stormTotal = activeStormTotal(supabase, alert.MountainID)
+				stormTotals[alert.MountainID] = stormTotal
+			}
+			emailData = append(emailData, email.EmailData{Location: alert.Location, Snowfall: alert.Snowfall, StormTotal: stormTotal})
 		}
 
 		sort.Slice(emailData, func(i, j int) bool {
@@ -123,6 +216,106 @@ func QueueOvernightAlertEmailTasks(client *asynq.Client, supabase supabase.Supab
 	}
 }
 
+func QueueAvalancheAlertEmailTasks(client *asynq.Client, supabaseClient supabase.SupabaseClient) {
+	subscriptions, err := supabaseClient.GetUserAvalancheSubscriptions()
+	if err != nil {
+		log.Printf("[*] Error getting avalanche subscriptions: %v", err)
+		return
+	}
+
+	now := time.Now()
+	// Users share mountains, each one's forecasts are only checked once
+	alerts := map[int]*avalanche.Alert{}
+	for _, user := range subscriptions {
+		var alertData []email.AvalancheAlertData
+		for _, mountain := range user.Mountains {
+			alert, checked := alerts[mountain.MountainID]
+			if !checked {
+				alert, err = avalanche.CheckAlert(supabaseClient, mountain.MountainID, avalanche.AlertDangerLevel, now)
+				if err != nil {
+					log.Printf("[*] Error checking avalanche danger for mountain %d: %v", mountain.MountainID, err)
+					continue
+				}
+				alerts[mountain.MountainID] = alert
+			}
+			if alert != nil {
+				alertData = append(alertData, avalancheAlertEmailData(mountain.Location, alert))
+			}
+		}
+		if len(alertData) == 0 {
+			continue
+		}
+
+		sort.SliceStable(alertData, func(i, j int) bool {
+			return alertData[i].Level > alertData[j].Level
+		})
+
+		payload, err := json.Marshal(tasks.AvalancheAlertEmailPayload{Email: user.Email, Alerts: alertData})
+		if err != nil {
+			log.Printf("[*] Error marshalling avalanche alert payload: %v", err)
+			continue
+		}
+
+		task := buildTask(tasks.TypeAvalancheAlertEmail, payload)
+
+		// A user gets at most one avalanche alert a day
+		info, err := client.Enqueue(task, asynq.TaskID(fmt.Sprintf("%s:%s:%s", tasks.TypeAvalancheAlertEmail, user.Email, now.Format("2006-01-02"))))
+
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			continue
+		}
+		if err != nil {
+			log.Printf("[*] Error enqueuing task: %v", err)
+		}
+		log.Printf("[*] Enqueued task: %v", info)
+	}
+}
+
+// Returns the total of the mountain's active storm, or 0 when it isn't snowing
+// or the storm can't be read, in which case the email leaves the column out
+func activeStormTotal(supabaseClient supabase.SupabaseClient, mountainID int) float64 {
+	storm, err := supabaseClient.GetActiveStorm(mountainID)
+	if err != nil {
+		log.Printf("[*] Error getting active storm for mountain %d: %v", mountainID, err)
+		return 0
+	}
+	if storm == nil {
+		return 0
+	}
+	return storm.Total
+}
+
+// Rates the danger bands of the forecast's first day
+func avalancheAlertEmailData(location string, alert *avalanche.Alert) email.AvalancheAlertData {
+	data := email.AvalancheAlertData{
+		Location:      location,
+		Rating:        scraping.DangerRating(alert.Level),
+		Level:         alert.Level,
+		AboveTreeline: "-",
+		NearTreeline:  "-",
+		BelowTreeline: "-",
+		Summary:       alert.Summary,
+		ForecastURL:   alert.ForecastURL,
+	}
+	if alert.Rose() {
+		data.PreviousRating = scraping.DangerRating(alert.PreviousLevel)
+	}
+	if len(alert.DangerLevels) > 0 {
+		today := alert.DangerLevels[0]
+		data.AboveTreeline = formatDangerRating(today.AboveTreeline)
+		data.NearTreeline = formatDangerRating(today.NearTreeline)
+		data.BelowTreeline = formatDangerRating(today.BelowTreeline)
+	}
+	return data
+}
+
+func formatDangerRating(rating scraping.AvalancheRating) string {
+	if rating.Level <= 0 {
+		return rating.Rating
+	}
+	return fmt.Sprintf("%d - %s", rating.Level, rating.Rating)
+}
+
 func buildTask(taskType string, payload []byte) *asynq.Task {
 	var task *asynq.Task
 	ENV := os.Getenv("ENV")
@@ -136,18 +329,19 @@ func buildTask(taskType string, payload []byte) *asynq.Task {
 	return task
 }
 
-// Used to determine whether or not the web scraping task should be queued
-// If the config's closing date is in the past, we should not queue this task or collect any data
-func isResortClosed(mountain string, supabase supabase.SupabaseClient) bool {
-	config := supabase.GetConfigByName(mountain)
+// Loads the resort's season calendar, which decides whether its web scraping
+// task should be queued. A calendar that fails to parse is logged and the
+// resort is scraped as if it were open, rather than silently dropped
+func resortCalendar(source configsource.Source, mountain string) (*season.Calendar, error) {
+	config, err := configsource.Load(source, mountain)
+	if err != nil {
+		return nil, err
+	}
 
-	if config.ClosingDate != "" {
-		const layout = "2006-01-02 5:00pm (MST)"
-		closingDate, err := time.Parse(layout, config.ClosingDate)
-		if err != nil {
-			log.Printf("Error parsing closing date: %v", err)
-		}
-		return time.Now().After(closingDate)
+	calendar, err := season.Parse(config.SeasonCalendar())
+	if err != nil {
+		log.Printf("[*] Error parsing season calendar for %s, scraping as open: %v", mountain, err)
+		calendar, _ = season.Parse(supabase.SeasonConfig{})
 	}
-	return false
+	return calendar, nil
 }
@@ -0,0 +1,287 @@
+package sanitize
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements kept by HTML. Anything else is unwrapped, keeping its text, and
+// every attribute except a link's href is dropped
+var allowedElements = map[atom.Atom]bool{
+	atom.P: true, atom.Br: true,
+	atom.Strong: true, atom.B: true, atom.Em: true, atom.I: true, atom.U: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true,
+	atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.A: true,
+}
+
+// Elements dropped along with everything inside them
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true,
+	atom.Embed: true, atom.Noscript: true, atom.Template: true, atom.Head: true,
+	atom.Title: true, atom.Svg: true, atom.Math: true, atom.Form: true,
+}
+
+// Elements that start a new block in the text and Markdown versions
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Ul: true, atom.Ol: true, atom.Li: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Blockquote: true, atom.Section: true, atom.Article: true, atom.Table: true, atom.Tr: true,
+}
+
+var allowedSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// HTML keeps only the allow-listed elements of a fragment: paragraphs, line
+// breaks, emphasis, lists, small headings, quotes and absolute links
+func HTML(fragment string) string {
+	nodes := parse(fragment)
+	var b strings.Builder
+	for _, node := range nodes {
+		renderHTML(&b, node)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// PlainText renders a fragment as text, with blank lines between paragraphs,
+// "- " before list items and links followed by their URL
+func PlainText(fragment string) string {
+	return renderText(parse(fragment), false)
+}
+
+// Markdown renders a fragment as Markdown
+func Markdown(fragment string) string {
+	return renderText(parse(fragment), true)
+}
+
+func parse(fragment string) []*html.Node {
+	context := &html.Node{Type: html.ElementNode, DataAtom: atom.Div, Data: "div"}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		// The tokenizer accepts any input, so this only happens on a read error
+		return []*html.Node{{Type: html.TextNode, Data: fragment}}
+	}
+	return nodes
+}
+
+func linkTarget(n *html.Node) string {
+	for _, attr := range n.Attr {
+		if attr.Namespace != "" || attr.Key != "href" {
+			continue
+		}
+		href, err := url.Parse(strings.TrimSpace(attr.Val))
+		if err != nil || !allowedSchemes[strings.ToLower(href.Scheme)] {
+			return ""
+		}
+		return href.String()
+	}
+	return ""
+}
+
+func renderHTML(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		// Comments and doctypes are dropped
+		return
+	}
+	if droppedElements[n.DataAtom] {
+		return
+	}
+
+	allowed := allowedElements[n.DataAtom]
+	var href string
+	if n.DataAtom == atom.A {
+		// Links to relative or script URLs keep their text only
+		href = linkTarget(n)
+		allowed = href != ""
+	}
+
+	if allowed {
+		b.WriteString("<" + n.Data)
+		if href != "" {
+			b.WriteString(` href="` + html.EscapeString(href) + `" rel="nofollow noopener noreferrer"`)
+		}
+		b.WriteString(">")
+		if n.DataAtom == atom.Br {
+			return
+		}
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		renderHTML(b, child)
+	}
+	if allowed {
+		b.WriteString("</" + n.Data + ">")
+	}
+}
+
+var (
+	// Includes non-breaking spaces, which pages use for spacing
+	spaces          = regexp.MustCompile(`[ \t\r\n\f\x{00a0}]+`)
+	extraBlankLines = regexp.MustCompile(`\n{3,}`)
+	// Characters with a meaning in Markdown text. Markdown renderers pass HTML
+	// through, so decoded entities such as &lt;script&gt; are encoded again
+	markdownSpecial = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, "`", "\\`", `[`, `\[`, `]`, `\]`,
+		`&`, `&amp;`, `<`, `&lt;`, `>`, `&gt;`)
+	// Characters that would end or break a link's (href)
+	markdownLinkTarget = strings.NewReplacer(`(`, `%28`, `)`, `%29`, ` `, `%20`, `<`, `%3C`, `>`, `%3E`)
+)
+
+// Collects blocks of text separated by blank lines
+type textRenderer struct {
+	markdown bool
+	blocks   []string
+	inline   strings.Builder
+}
+
+func renderText(nodes []*html.Node, markdown bool) string {
+	r := &textRenderer{markdown: markdown}
+	for _, node := range nodes {
+		r.node(node)
+	}
+	r.flush("")
+	return strings.TrimSpace(extraBlankLines.ReplaceAllString(strings.Join(r.blocks, "\n\n"), "\n\n"))
+}
+
+// Ends the current block, giving it the prefix of the element it belongs to
+func (r *textRenderer) flush(prefix string) {
+	var lines []string
+	for _, line := range strings.Split(r.inline.String(), "\n") {
+		lines = append(lines, strings.TrimSpace(spaces.ReplaceAllString(line, " ")))
+	}
+	r.inline.Reset()
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	if text != "" {
+		r.blocks = append(r.blocks, prefix+text)
+	}
+}
+
+func (r *textRenderer) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		text := n.Data
+		if r.markdown {
+			text = markdownSpecial.Replace(text)
+		}
+		r.inline.WriteString(text)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	if droppedElements[n.DataAtom] {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		r.inline.WriteString("\n")
+		return
+	case atom.Ul, atom.Ol:
+		r.flush("")
+		r.list(n)
+		return
+	case atom.Strong, atom.B:
+		r.wrap(n, "**")
+		return
+	case atom.Em, atom.I:
+		r.wrap(n, "*")
+		return
+	case atom.A:
+		r.link(n)
+		return
+	}
+
+	if !blockElements[n.DataAtom] {
+		r.children(n)
+		return
+	}
+	r.flush("")
+	r.children(n)
+	r.flush(r.blockPrefix(n))
+}
+
+func (r *textRenderer) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		r.node(child)
+	}
+}
+
+func (r *textRenderer) blockPrefix(n *html.Node) string {
+	if !r.markdown {
+		return ""
+	}
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3:
+		return "### "
+	case atom.H4, atom.H5, atom.H6:
+		return "#### "
+	case atom.Blockquote:
+		return "> "
+	}
+	return ""
+}
+
+// Emphasis is only marked up in Markdown
+func (r *textRenderer) wrap(n *html.Node, marker string) {
+	if !r.markdown {
+		r.children(n)
+		return
+	}
+	r.inline.WriteString(marker)
+	r.children(n)
+	r.inline.WriteString(marker)
+}
+
+func (r *textRenderer) link(n *html.Node) {
+	href := linkTarget(n)
+	if href == "" {
+		r.children(n)
+		return
+	}
+	if r.markdown {
+		r.inline.WriteString("[")
+		r.children(n)
+		r.inline.WriteString("](" + markdownLinkTarget.Replace(href) + ")")
+		return
+	}
+	start := r.inline.Len()
+	r.children(n)
+	if strings.TrimSpace(r.inline.String()[start:]) != href {
+		r.inline.WriteString(" (" + href + ")")
+	}
+}
+
+// Lists are one block with an item per line
+func (r *textRenderer) list(n *html.Node) {
+	var items []string
+	number := 0
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li {
+			continue
+		}
+		number++
+		item := &textRenderer{markdown: r.markdown}
+		item.children(child)
+		item.flush("")
+		text := strings.Join(item.blocks, " ")
+		if text == "" {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + ". "
+		}
+		items = append(items, marker+strings.ReplaceAll(text, "\n", " "))
+	}
+	if len(items) > 0 {
+		r.blocks = append(r.blocks, strings.Join(items, "\n"))
+	}
+}
@@ -0,0 +1,76 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const summary = `<p style="color: red" class="lead">Strong winds are drifting snow onto <strong>north &amp; east</strong> facing slopes.</p>` +
+	`<script>alert("x")</script>` +
+	`<p>Read the <a href="https://avalanche.state.co.us/forecasts" onclick="steal()">full forecast</a> or <a href="javascript:alert(1)">this</a>.<!-- internal note --></p>` +
+	`<ul><li>Avoid <em>wind-loaded</em> slopes</li><li>Watch for cracking</li></ul>` +
+	`<div><span>Issued by CAIC</span><iframe src="https://example.com"></iframe></div>`
+
+func TestHTML(t *testing.T) {
+	assert.Equal(t,
+		`<p>Strong winds are drifting snow onto <strong>north &amp; east</strong> facing slopes.</p>`+
+			`<p>Read the <a href="https://avalanche.state.co.us/forecasts" rel="nofollow noopener noreferrer">full forecast</a> or this.</p>`+
+			`<ul><li>Avoid <em>wind-loaded</em> slopes</li><li>Watch for cracking</li></ul>`+
+			`Issued by CAIC`,
+		HTML(summary))
+
+	t.Run("plain text is escaped", func(t *testing.T) {
+		assert.Equal(t, "Cornices &lt; 2 ft &amp; growing", HTML("Cornices < 2 ft & growing"))
+	})
+
+	t.Run("line breaks are kept", func(t *testing.T) {
+		assert.Equal(t, "First<br><br>Second", HTML("First<br><br/>Second"))
+	})
+
+	t.Run("attributes can't break out of the link", func(t *testing.T) {
+		assert.Equal(t, `<a href="https://example.com/?q=&#34;&gt;&lt;script&gt;" rel="nofollow noopener noreferrer">x</a>`, HTML(`<a href='https://example.com/?q="><script>'>x</a>`))
+	})
+}
+
+func TestPlainText(t *testing.T) {
+	assert.Equal(t, "Strong winds are drifting snow onto north & east facing slopes.\n\n"+
+		"Read the full forecast (https://avalanche.state.co.us/forecasts) or this.\n\n"+
+		"- Avoid wind-loaded slopes\n- Watch for cracking\n\n"+
+		"Issued by CAIC",
+		PlainText(summary))
+
+	t.Run("page summary joined with line breaks", func(t *testing.T) {
+		assert.Equal(t, "First paragraph.\n\nSecond paragraph.", PlainText("First   paragraph.<br><br>  Second&nbsp; paragraph.\n"))
+	})
+
+	t.Run("link text that is its URL", func(t *testing.T) {
+		assert.Equal(t, "See https://nwac.us/", PlainText(`See <a href="https://nwac.us/">https://nwac.us/</a>`))
+	})
+}
+
+func TestMarkdown(t *testing.T) {
+	assert.Equal(t, "Strong winds are drifting snow onto **north &amp; east** facing slopes.\n\n"+
+		"Read the [full forecast](https://avalanche.state.co.us/forecasts) or this.\n\n"+
+		"- Avoid *wind-loaded* slopes\n- Watch for cracking\n\n"+
+		"Issued by CAIC",
+		Markdown(summary))
+
+	t.Run("special characters are escaped", func(t *testing.T) {
+		assert.Equal(t, `Size D1\_5 \*estimated\*`, Markdown("Size D1_5 *estimated*"))
+	})
+
+	t.Run("decoded HTML is escaped", func(t *testing.T) {
+		assert.Equal(t, "&lt;script&gt;alert(1)&lt;/script&gt; &amp; more", Markdown("&lt;script&gt;alert(1)&lt;/script&gt; &amp; more"))
+	})
+
+	t.Run("link targets can't end the link early", func(t *testing.T) {
+		assert.Equal(t, "[forecast](https://example.com/a%28b%29?q=Loveland%20Pass)",
+			Markdown(`<a href="https://example.com/a(b)?q=Loveland Pass">forecast</a>`))
+	})
+
+	t.Run("headings, quotes and numbered lists", func(t *testing.T) {
+		assert.Equal(t, "### Bottom Line\n\n> Stay off steep slopes\n\n1. Wind slab\n2. Persistent slab",
+			Markdown("<h2>Bottom Line</h2><blockquote>Stay off steep slopes</blockquote><ol><li>Wind slab</li><li>Persistent slab</li></ol>"))
+	})
+}
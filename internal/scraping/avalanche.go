@@ -7,6 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"powderhoundgo/internal/sanitize"
+
+	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/chromedp"
 )
 
@@ -20,24 +23,40 @@ const (
 	AvalancheSummaryTwoSelector = ".sm\\:pt-4 > p:nth-child(2)"
 	IssueDateSelector           = "span.whitespace-nowrap:nth-child(3)"
 
-	// Day label selectors
-	DayOneSelector = "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(1) > div:nth-child(1) > div:nth-child(1)"
-	DayTwoSelector = "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(1) > div:nth-child(1)"
-
-	// Tree line selectors
-	AboveTreeLineDayOneSelector = "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(1) > div:nth-child(2) > p:nth-child(1) > b:nth-child(1)"
-	AboveTreeLineDayTwoSelector = "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(2) > div:nth-child(1) > div:nth-child(1) > p:nth-child(1) > b:nth-child(1)"
-	NearTreeLineDayOneSelector  = "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(2) > div:nth-child(2) > p:nth-child(1) > b:nth-child(1)"
-	NearTreeLineDayTwoSelector  = "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(2) > div:nth-child(2) > div:nth-child(1) > p:nth-child(1) > b:nth-child(1)"
-	BelowTreeLineDayOneSelector = "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(3) > div:nth-child(2) > p:nth-child(1) > b:nth-child(1)"
-	BelowTreeLineDayTwoSelector = "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(2) > div:nth-child(3) > div:nth-child(1) > p:nth-child(1) > b:nth-child(1)"
+	// One column per forecast day: today, tomorrow and any outlook days
+	DangerDaysSelector = "div.mt-4:nth-child(1) > div:nth-child(1) > div"
+
+	// Day label selector, %d is the day's column from 1
+	DayLabelSelector = "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(%d) > div:nth-child(1) > div:nth-child(1)"
+
+	// Tree line selector, with the day's column, the band's row (1 above, 2
+	// near and 3 below treeline) and the rating's cell in the row
+	TreeLineSelector = "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(%d) > div:nth-child(2) > div:nth-child(%d) > div:nth-child(%d) > p:nth-child(1) > b:nth-child(1)"
 )
 
+// treeLineSelectors returns the above, near and below treeline selectors of
+// a day. The first day has the band names in its rows, so its ratings are in
+// the second cell; the other days only have the ratings
+func treeLineSelectors(day int) [3]string {
+	cell := 1
+	if day == 1 {
+		cell = 2
+	}
+	var selectors [3]string
+	for band := range selectors {
+		selectors[band] = fmt.Sprintf(TreeLineSelector, day, band+1, cell)
+	}
+	return selectors
+}
+
 // MountainCoordinates represents a mountain's location for avalanche forecasting
 type MountainCoordinates struct {
 	MountainID int     `json:"mountain_id"`
 	Lat        float64 `json:"lat"`
 	Lon        float64 `json:"lon"`
+	// Center ID such as "CAIC" or "NWAC", optional. Picks the providers
+	// instead of the coordinates
+	AvalancheCenter string `json:"avalanche_center"`
 }
 
 // AvalancheRating represents the danger level at a specific elevation
@@ -48,21 +67,103 @@ type AvalancheRating struct {
 
 // AvalancheDangerLevel represents danger levels for a specific day
 type AvalancheDangerLevel struct {
-	Date          string          `json:"date"`
-	AboveTreeline AvalancheRating `json:"above_treeline"`
-	NearTreeline  AvalancheRating `json:"near_treeline"`
-	BelowTreeline AvalancheRating `json:"below_treeline"`
+	Date string `json:"date"`
+	// The highest of the day's ratings
+	OverallDangerLevel int             `json:"overall_danger_level"`
+	AboveTreeline      AvalancheRating `json:"above_treeline"`
+	NearTreeline       AvalancheRating `json:"near_treeline"`
+	BelowTreeline      AvalancheRating `json:"below_treeline"`
+}
+
+func newDangerLevel(date string, above, near, below AvalancheRating) AvalancheDangerLevel {
+	return AvalancheDangerLevel{
+		Date:               date,
+		OverallDangerLevel: max(above.Level, near.Level, below.Level),
+		AboveTreeline:      above,
+		NearTreeline:       near,
+		BelowTreeline:      below,
+	}
+}
+
+// A forecast's overall danger is today's, the first day's
+func overallDangerLevel(days []AvalancheDangerLevel) int {
+	if len(days) == 0 {
+		return 0
+	}
+	return days[0].OverallDangerLevel
 }
 
 // AvalancheForecast represents the complete forecast for a mountain
 type AvalancheForecast struct {
-	MountainID         int                    `json:"mountain_id"`
-	AvalancheSummary   string                 `json:"avalanche_summary"`
-	IssueDate          string                 `json:"issue_date"`
+	MountainID       int    `json:"mountain_id"`
+	AvalancheSummary string `json:"avalanche_summary"`
+	// The summary as plain text and as Markdown, for emails, SMS and the API
+	SummaryText     string `json:"avalanche_summary_text"`
+	SummaryMarkdown string `json:"avalanche_summary_markdown"`
+	// Zero when the page's issue date couldn't be read
+	IssueDate          time.Time              `json:"issue_date"`
 	OverallDangerLevel int                    `json:"overall_danger_level"`
 	DangerLevels       []AvalancheDangerLevel `json:"danger_levels"`
+	Problems           []AvalancheProblem     `json:"avalanche_problems"`
 	ForecastURL        string                 `json:"forecast_url"`
-	UpdatedAt          time.Time              `json:"updated_at"`
+	// The forecast zone the forecast was scraped for, empty when the mountain
+	// isn't in the zones file
+	ZoneID    string    `json:"zone_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// sanitize keeps only the allow-listed markup of the summary and the
+// problems' travel advice, since centers' feeds and the page can carry any
+// HTML, and fills in the text and Markdown versions of the summary
+func (f *AvalancheForecast) sanitize() {
+	f.SummaryText = sanitize.PlainText(f.AvalancheSummary)
+	f.SummaryMarkdown = sanitize.Markdown(f.AvalancheSummary)
+	f.AvalancheSummary = sanitize.HTML(f.AvalancheSummary)
+	for i := range f.Problems {
+		f.Problems[i].TravelAdvice = sanitize.HTML(f.Problems[i].TravelAdvice)
+	}
+}
+
+// Layouts the forecast page has shown its issue time in, once any "Issued"
+// label is trimmed off
+var issueDateLayouts = []string{
+	"3:04 PM Mon, Jan 2, 2006",
+	"Mon, Jan 2, 2006 3:04 PM",
+	"Monday, January 2, 2006 at 3:04 PM",
+	"January 2, 2006 at 3:04 PM",
+	"Jan 2, 2006 3:04 PM",
+	"1/2/2006 3:04 PM",
+}
+
+// parseIssueDate parses an issue time from a feed or the page. Times without
+// an offset are read in the center's timezone, and the result is returned in
+// it
+func parseIssueDate(text string, location *time.Location) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if issueDate, err := time.Parse(time.RFC3339, text); err == nil {
+		return issueDate.In(location), nil
+	}
+	if issueDate, err := time.ParseInLocation("2006-01-02T15:04:05", text, location); err == nil {
+		return issueDate, nil
+	}
+
+	text = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(text, "Issued"), ":"))
+	for _, layout := range issueDateLayouts {
+		if issueDate, err := time.ParseInLocation(layout, text, location); err == nil {
+			return issueDate, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized issue date %q", text)
+}
+
+// Falls back to UTC so a missing zoneinfo database doesn't stop forecasts
+func loadLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("Warning: unknown timezone %q, using UTC: %v", name, err)
+		return time.UTC
+	}
+	return location
 }
 
 // parseTreeLineData parses the tree line text (e.g., "3-Considerable") into level and rating
@@ -90,15 +191,45 @@ func parseTreeLineData(text string) AvalancheRating {
 	return AvalancheRating{Level: level, Rating: rating}
 }
 
-// ScrapeAvalancheForecast scrapes avalanche forecast data for a given mountain
+// ScrapeAvalancheForecast fetches the avalanche forecast for a given mountain
+// from the first provider in the default registry that has one
 func ScrapeAvalancheForecast(mountain MountainCoordinates) (*AvalancheForecast, error) {
-	ctx, cancel := chromedp.NewContext(context.Background(), chromedp.WithLogf(log.Printf))
-	defer cancel()
+	return DefaultAvalancheRegistry().Forecast(mountain, time.Now())
+}
+
+// CAIC's page picks the forecast from coordinates in the query string
+const caicForecastPageURL = "https://avalanche.state.co.us/?lat="
+
+func avalancheForecastURL(mountain MountainCoordinates) string {
+	return fmt.Sprintf(caicForecastPageURL+"%f&lng=%f", mountain.Lat, mountain.Lon)
+}
+
+// ForMountain returns a copy of a forecast scraped for another mountain in
+// the same zone. CAIC links are built from the mountain's coordinates so they
+// are rebuilt, other centers link to the zone and are kept
+func (f *AvalancheForecast) ForMountain(mountain MountainCoordinates, zoneID string) *AvalancheForecast {
+	forecast := *f
+	forecast.MountainID = mountain.MountainID
+	forecast.ZoneID = zoneID
+	if strings.HasPrefix(forecast.ForecastURL, caicForecastPageURL) {
+		forecast.ForecastURL = avalancheForecastURL(mountain)
+	}
+	return &forecast
+}
 
-	ctx, cancel = context.WithTimeout(ctx, 120*time.Second)
+// scrapeAvalanchePage scrapes the forecast from the rendered CAIC page
+func scrapeAvalanchePage(mountain MountainCoordinates) (*AvalancheForecast, error) {
+	tabCtx, cancel, err := newBrowserContext(context.Background())
+	if err != nil {
+		return nil, err
+	}
 	defer cancel()
+	browserLog := listenBrowserLog(tabCtx)
+
+	ctx, cancelTimeout := context.WithTimeout(tabCtx, 120*time.Second)
+	defer cancelTimeout()
 
-	forecastURL := fmt.Sprintf("https://avalanche.state.co.us/?lat=%f&lng=%f", mountain.Lat, mountain.Lon)
+	forecastURL := avalancheForecastURL(mountain)
 	log.Printf("Navigating to: %s", forecastURL)
 
 	// Navigate to the page
@@ -106,14 +237,15 @@ func ScrapeAvalancheForecast(mountain MountainCoordinates) (*AvalancheForecast,
 
 	// Wait for the main content container to be visible (indicates JS has loaded)
 	log.Printf("Waiting for content to load...")
-	runChromeDP(ctx, chromedp.WaitVisible(AvalancheContainerSelector))
+	if err := runChromeDP(ctx, chromedp.WaitVisible(AvalancheContainerSelector)); err != nil {
+		err = fmt.Errorf("avalanche forecast page did not load: %w", err)
+		return nil, captureBrowserFailure(tabCtx, fmt.Sprintf("avalanche-%d", mountain.MountainID), browserLog, err)
+	}
 	log.Printf("Content container visible, extracting data...")
 
 	// Extract all data in batched operations
 	var avalancheSummaryOne, avalancheSummaryTwo string
-	var issueDate, dayOne, dayTwo string
-	var aboveTreeLineDayOneText, nearTreeLineDayOneText, belowTreeLineDayOneText string
-	var aboveTreeLineDayTwoText, nearTreeLineDayTwoText, belowTreeLineDayTwoText string
+	var issueDate string
 
 	// Batch 1: Get summary and issue date
 	runChromeDP(ctx,
@@ -128,25 +260,30 @@ func ScrapeAvalancheForecast(mountain MountainCoordinates) (*AvalancheForecast,
 		chromedp.Text(IssueDateSelector, &issueDate, chromedp.ByQuery),
 	)
 
-	// Batch 2: Get day labels
-	runChromeDP(ctx,
-		chromedp.Text(DayOneSelector, &dayOne, chromedp.ByQuery),
-		chromedp.Text(DayTwoSelector, &dayTwo, chromedp.ByQuery),
-	)
-
-	// Batch 3: Get tree line ratings for day one
-	runChromeDP(ctx,
-		chromedp.Text(AboveTreeLineDayOneSelector, &aboveTreeLineDayOneText, chromedp.ByQuery),
-		chromedp.Text(NearTreeLineDayOneSelector, &nearTreeLineDayOneText, chromedp.ByQuery),
-		chromedp.Text(BelowTreeLineDayOneSelector, &belowTreeLineDayOneText, chromedp.ByQuery),
-	)
-
-	// Batch 4: Get tree line ratings for day two
-	runChromeDP(ctx,
-		chromedp.Text(AboveTreeLineDayTwoSelector, &aboveTreeLineDayTwoText, chromedp.ByQuery),
-		chromedp.Text(NearTreeLineDayTwoSelector, &nearTreeLineDayTwoText, chromedp.ByQuery),
-		chromedp.Text(BelowTreeLineDayTwoSelector, &belowTreeLineDayTwoText, chromedp.ByQuery),
-	)
+	// Batch 2: Count the forecast days, the page adds outlook days after
+	// tomorrow when the center publishes them
+	var dayNodes []*cdp.Node
+	runChromeDP(ctx, chromedp.Nodes(DangerDaysSelector, &dayNodes, chromedp.ByQueryAll))
+
+	// Batch 3: Get each day's label and tree line ratings
+	var dangerLevels []AvalancheDangerLevel
+	for day := 1; day <= len(dayNodes); day++ {
+		var label string
+		var treeLineTexts [3]string
+		selectors := treeLineSelectors(day)
+		runChromeDP(ctx,
+			chromedp.Text(fmt.Sprintf(DayLabelSelector, day), &label, chromedp.ByQuery),
+			chromedp.Text(selectors[0], &treeLineTexts[0], chromedp.ByQuery),
+			chromedp.Text(selectors[1], &treeLineTexts[1], chromedp.ByQuery),
+			chromedp.Text(selectors[2], &treeLineTexts[2], chromedp.ByQuery),
+		)
+		dangerLevels = append(dangerLevels, newDangerLevel(
+			strings.TrimSpace(label),
+			parseTreeLineData(treeLineTexts[0]),
+			parseTreeLineData(treeLineTexts[1]),
+			parseTreeLineData(treeLineTexts[2]),
+		))
+	}
 
 	// Build the summary
 	var avalancheSummary string
@@ -157,45 +294,24 @@ func ScrapeAvalancheForecast(mountain MountainCoordinates) (*AvalancheForecast,
 		avalancheSummary = avalancheSummaryOne
 	}
 
-	// Parse tree line data
-	aboveTreeLineDayOne := parseTreeLineData(aboveTreeLineDayOneText)
-	nearTreeLineDayOne := parseTreeLineData(nearTreeLineDayOneText)
-	belowTreeLineDayOne := parseTreeLineData(belowTreeLineDayOneText)
-
-	aboveTreeLineDayTwo := parseTreeLineData(aboveTreeLineDayTwoText)
-	nearTreeLineDayTwo := parseTreeLineData(nearTreeLineDayTwoText)
-	belowTreeLineDayTwo := parseTreeLineData(belowTreeLineDayTwoText)
-
-	// Build danger levels
-	dangerDayOne := AvalancheDangerLevel{
-		Date:          dayOne,
-		AboveTreeline: aboveTreeLineDayOne,
-		NearTreeline:  nearTreeLineDayOne,
-		BelowTreeline: belowTreeLineDayOne,
-	}
-
-	dangerDayTwo := AvalancheDangerLevel{
-		Date:          dayTwo,
-		AboveTreeline: aboveTreeLineDayTwo,
-		NearTreeline:  nearTreeLineDayTwo,
-		BelowTreeline: belowTreeLineDayTwo,
+	// The page's wording isn't stable, so a date it can't parse is left unset
+	// rather than failing the scrape, and the forecast is compared against the
+	// stored one when it's saved
+	now := time.Now()
+	issuedAt, err := parseIssueDate(issueDate, loadLocation(caicTimezone))
+	if err != nil {
+		log.Printf("Warning: %v, leaving the issue date unset", err)
+		issuedAt = time.Time{}
 	}
 
-	// Calculate overall danger level (max of all day one levels)
-	overallDangerLevel := max(
-		dangerDayOne.AboveTreeline.Level,
-		dangerDayOne.NearTreeline.Level,
-		dangerDayOne.BelowTreeline.Level,
-	)
-
 	forecast := &AvalancheForecast{
 		MountainID:         mountain.MountainID,
 		AvalancheSummary:   avalancheSummary,
-		IssueDate:          issueDate,
-		OverallDangerLevel: overallDangerLevel,
-		DangerLevels:       []AvalancheDangerLevel{dangerDayOne, dangerDayTwo},
+		IssueDate:          issuedAt,
+		OverallDangerLevel: overallDangerLevel(dangerLevels),
+		DangerLevels:       dangerLevels,
 		ForecastURL:        forecastURL,
-		UpdatedAt:          time.Now(),
+		UpdatedAt:          now,
 	}
 
 	log.Printf("Successfully scraped avalanche forecast for mountain %d", mountain.MountainID)
@@ -0,0 +1,285 @@
+package scraping
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// The National Avalanche Center's API behind avalanche.org, which also
+	// hosts the forecasts of most regional centers
+	AvalancheOrgBaseURL = "https://api.avalanche.org/v2/public"
+
+	nwacCenterID = "NWAC"
+)
+
+// Washington and northern Oregon, from the Olympics to Mt Hood
+var nwacBounds = geoBounds{minLat: 44.9, maxLat: 49.1, minLon: -124.8, maxLon: -120.0}
+
+// Names of the North American danger scale levels; -1 and 0 are both unrated
+var dangerScale = []string{"No Rating", "Low", "Moderate", "Considerable", "High", "Extreme"}
+
+// DangerRating names a level of the danger scale, e.g. "Considerable" for 3
+func DangerRating(level int) string {
+	return ratingForLevel(level).Rating
+}
+
+func ratingForLevel(level int) AvalancheRating {
+	if level < 0 || level >= len(dangerScale) {
+		level = 0
+	}
+	return AvalancheRating{Level: level, Rating: dangerScale[level]}
+}
+
+// A forecast zone from the map layer, with today's overall danger
+type avalancheOrgZone struct {
+	ID         int `json:"id"`
+	Properties struct {
+		Name         string `json:"name"`
+		CenterID     string `json:"center_id"`
+		Timezone     string `json:"timezone"`
+		OffSeason    bool   `json:"off_season"`
+		TravelAdvice string `json:"travel_advice"`
+		DangerLevel  int    `json:"danger_level"`
+		Link         string `json:"link"`
+		StartDate    string `json:"start_date"`
+	} `json:"properties"`
+	Geometry geoJSONGeometry `json:"geometry"`
+}
+
+type avalancheOrgMapLayer struct {
+	Features []avalancheOrgZone `json:"features"`
+}
+
+func (l avalancheOrgMapLayer) zoneAt(lat, lon float64) (avalancheOrgZone, bool) {
+	for _, zone := range l.Features {
+		inside, err := zone.Geometry.contains(lat, lon)
+		if err != nil {
+			log.Printf("Warning: skipping forecast zone %d: %v", zone.ID, err)
+			continue
+		}
+		if inside {
+			return zone, true
+		}
+	}
+	return avalancheOrgZone{}, false
+}
+
+// AvalancheOrgClient reads the national avalanche.org map layer. It covers
+// every US center's zones but only has the overall danger for today, so the
+// forecast it returns has no elevation bands
+type AvalancheOrgClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewAvalancheOrgClient() *AvalancheOrgClient {
+	return &AvalancheOrgClient{BaseURL: AvalancheOrgBaseURL, HTTPClient: httpClient}
+}
+
+func (c *AvalancheOrgClient) Name() string {
+	return "avalanche.org"
+}
+
+func (c *AvalancheOrgClient) Covers(mountain MountainCoordinates) bool {
+	return true
+}
+
+func (c *AvalancheOrgClient) Forecast(mountain MountainCoordinates, now time.Time) (*AvalancheForecast, error) {
+	var layer avalancheOrgMapLayer
+	if err := getJSON(c.HTTPClient, c.BaseURL+"/products/map-layer", &layer); err != nil {
+		return nil, err
+	}
+	zone, ok := layer.zoneAt(mountain.Lat, mountain.Lon)
+	if !ok {
+		return nil, fmt.Errorf("no avalanche.org forecast zone contains %f,%f", mountain.Lat, mountain.Lon)
+	}
+	if zone.Properties.OffSeason {
+		return nil, fmt.Errorf("%s zone %s is off season", zone.Properties.CenterID, zone.Properties.Name)
+	}
+	// Start dates are local to the zone's center
+	issueDate, err := parseIssueDate(zone.Properties.StartDate, loadLocation(zone.Properties.Timezone))
+	if err != nil {
+		return nil, err
+	}
+
+	return &AvalancheForecast{
+		MountainID:         mountain.MountainID,
+		AvalancheSummary:   strings.TrimSpace(zone.Properties.TravelAdvice),
+		IssueDate:          issueDate,
+		OverallDangerLevel: ratingForLevel(zone.Properties.DangerLevel).Level,
+		ForecastURL:        zone.Properties.Link,
+		UpdatedAt:          now,
+	}, nil
+}
+
+// A forecast product from /product, the full forecast for one zone
+type nacProduct struct {
+	PublishedTime string       `json:"published_time"`
+	ExpiresTime   string       `json:"expires_time"`
+	BottomLine    string       `json:"bottom_line"`
+	Danger        []nacDanger  `json:"danger"`
+	Problems      []nacProblem `json:"forecast_avalanche_problems"`
+}
+
+type nacProblem struct {
+	Name       string `json:"name"`
+	Likelihood string `json:"likelihood"`
+	// e.g. "northeast middle" for northeast aspects near treeline
+	Location []string `json:"location"`
+	// Smallest and largest expected size, e.g. ["1", "2.5"]
+	Size       []string `json:"size"`
+	Discussion string   `json:"discussion"`
+}
+
+var nacAspects = map[string]string{
+	"north":     "N",
+	"northeast": "NE",
+	"east":      "E",
+	"southeast": "SE",
+	"south":     "S",
+	"southwest": "SW",
+	"west":      "W",
+	"northwest": "NW",
+}
+
+var nacElevations = map[string]string{
+	"upper":  ElevationAboveTreeline,
+	"middle": ElevationNearTreeline,
+	"lower":  ElevationBelowTreeline,
+}
+
+func mapNACProblem(problem nacProblem) AvalancheProblem {
+	mapped := AvalancheProblem{
+		Type:         titleCase(problem.Name),
+		Likelihood:   titleCase(problem.Likelihood),
+		TravelAdvice: strings.TrimSpace(problem.Discussion),
+	}
+	if len(problem.Size) > 0 {
+		mapped.MinSize = parseProblemSize(problem.Size[0])
+		mapped.MaxSize = parseProblemSize(problem.Size[len(problem.Size)-1])
+	}
+	var locations []aspectElevation
+	for _, value := range problem.Location {
+		aspect, elevation, ok := strings.Cut(strings.ToLower(value), " ")
+		if !ok {
+			continue
+		}
+		locations = append(locations, aspectElevation{nacAspects[aspect], nacElevations[elevation]})
+	}
+	mapped.setLocations(locations)
+	return mapped
+}
+
+type nacDanger struct {
+	Upper    int    `json:"upper"`
+	Middle   int    `json:"middle"`
+	Lower    int    `json:"lower"`
+	ValidDay string `json:"valid_day"`
+}
+
+// Days in the order they're forecast
+var nacValidDays = map[string]int{"current": 0, "tomorrow": 1}
+
+// NACClient fetches a regional center's full forecasts from the avalanche.org
+// API, which most centers outside Colorado and Utah publish through
+type NACClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	CenterID   string
+	bounds     geoBounds
+}
+
+// NewNWACClient returns the provider for the Northwest Avalanche Center
+func NewNWACClient() *NACClient {
+	return &NACClient{BaseURL: AvalancheOrgBaseURL, HTTPClient: httpClient, CenterID: nwacCenterID, bounds: nwacBounds}
+}
+
+func (c *NACClient) Name() string {
+	return strings.ToLower(c.CenterID)
+}
+
+func (c *NACClient) Covers(mountain MountainCoordinates) bool {
+	return coversRegion(mountain, c.CenterID, c.bounds)
+}
+
+func (c *NACClient) Forecast(mountain MountainCoordinates, now time.Time) (*AvalancheForecast, error) {
+	var layer avalancheOrgMapLayer
+	if err := getJSON(c.HTTPClient, fmt.Sprintf("%s/products/map-layer/%s", c.BaseURL, c.CenterID), &layer); err != nil {
+		return nil, err
+	}
+	zone, ok := layer.zoneAt(mountain.Lat, mountain.Lon)
+	if !ok {
+		return nil, fmt.Errorf("no %s forecast zone contains %f,%f", c.CenterID, mountain.Lat, mountain.Lon)
+	}
+	if zone.Properties.OffSeason {
+		return nil, fmt.Errorf("%s zone %s is off season", c.CenterID, zone.Properties.Name)
+	}
+
+	var product nacProduct
+	url := fmt.Sprintf("%s/product?type=forecast&center_id=%s&zone_id=%d", c.BaseURL, c.CenterID, zone.ID)
+	if err := getJSON(c.HTTPClient, url, &product); err != nil {
+		return nil, err
+	}
+
+	forecast, err := mapNACProduct(product, zone.Properties.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	forecast.MountainID = mountain.MountainID
+	forecast.ForecastURL = zone.Properties.Link
+	forecast.UpdatedAt = now
+	return forecast, nil
+}
+
+// Forecasts are valid through the end of the day they expire on in the
+// center's timezone, so that's the date of the "current" day
+func mapNACProduct(product nacProduct, timezone string) (*AvalancheForecast, error) {
+	location := loadLocation(timezone)
+	issueDate, err := parseIssueDate(product.PublishedTime, location)
+	if err != nil {
+		return nil, err
+	}
+	var firstDay time.Time
+	if expires, err := time.Parse(time.RFC3339, product.ExpiresTime); err == nil {
+		firstDay = expires.In(location)
+	}
+
+	days := append([]nacDanger(nil), product.Danger...)
+	sort.SliceStable(days, func(i, j int) bool { return nacValidDays[days[i].ValidDay] < nacValidDays[days[j].ValidDay] })
+
+	var dangerLevels []AvalancheDangerLevel
+	for _, day := range days {
+		offset, ok := nacValidDays[day.ValidDay]
+		if !ok {
+			continue
+		}
+		var date string
+		if !firstDay.IsZero() {
+			date = firstDay.AddDate(0, 0, offset).Format("2006-01-02")
+		}
+		dangerLevels = append(dangerLevels, newDangerLevel(
+			date,
+			ratingForLevel(day.Upper),
+			ratingForLevel(day.Middle),
+			ratingForLevel(day.Lower),
+		))
+	}
+
+	var problems []AvalancheProblem
+	for _, problem := range product.Problems {
+		problems = append(problems, mapNACProblem(problem))
+	}
+
+	return &AvalancheForecast{
+		AvalancheSummary:   strings.TrimSpace(product.BottomLine),
+		IssueDate:          issueDate,
+		OverallDangerLevel: overallDangerLevel(dangerLevels),
+		DangerLevels:       dangerLevels,
+		Problems:           problems,
+	}, nil
+}
@@ -0,0 +1,151 @@
+package scraping
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Serves the recorded avalanche.org responses by path
+func newAvalancheOrgServer(t *testing.T) *httptest.Server {
+	files := map[string]string{
+		"/products/map-layer":      "map-layer.json",
+		"/products/map-layer/NWAC": "map-layer-nwac.json",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/product" {
+			query := r.URL.Query()
+			if query.Get("type") == "forecast" && query.Get("center_id") == "NWAC" && query.Get("zone_id") == "1653" {
+				http.ServeFile(w, r, filepath.Join("testdata", "avalanche-org", "nwac-product.json"))
+				return
+			}
+		}
+		if file, ok := files[r.URL.Path]; ok {
+			http.ServeFile(w, r, filepath.Join("testdata", "avalanche-org", file))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAvalancheOrgForecast(t *testing.T) {
+	server := newAvalancheOrgServer(t)
+	client := &AvalancheOrgClient{BaseURL: server.URL, HTTPClient: server.Client()}
+	now := time.Date(2024, 1, 11, 15, 0, 0, 0, time.UTC)
+
+	snowbird := MountainCoordinates{MountainID: 40, Lat: 40.5829, Lon: -111.6556}
+	forecast, err := client.Forecast(snowbird, now)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 40, forecast.MountainID)
+	assert.Equal(t, "Dangerous avalanche conditions exist on steep wind-drifted slopes. Careful snowpack evaluation and conservative decision-making are essential.", forecast.AvalancheSummary)
+	assert.Equal(t, 3, forecast.OverallDangerLevel)
+	assert.Empty(t, forecast.DangerLevels)
+	assert.Equal(t, "https://utahavalanchecenter.org/forecast/salt-lake", forecast.ForecastURL)
+	assert.Equal(t, now, forecast.UpdatedAt)
+	if forecast.IssueDate.Location() != time.UTC {
+		// Start dates are local to the center, 07:19 in Salt Lake City
+		assert.Equal(t, time.Date(2024, 1, 11, 14, 19, 0, 0, time.UTC), forecast.IssueDate.UTC())
+	}
+
+	t.Run("unrated zone", func(t *testing.T) {
+		bridger := MountainCoordinates{MountainID: 41, Lat: 45.8174, Lon: -110.8966}
+		forecast, err := client.Forecast(bridger, now)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, forecast.OverallDangerLevel)
+	})
+
+	t.Run("outside every zone", func(t *testing.T) {
+		_, err := client.Forecast(MountainCoordinates{Lat: 30.0, Lon: -90.0}, now)
+		assert.ErrorContains(t, err, "no avalanche.org forecast zone contains")
+	})
+}
+
+func TestNWACForecast(t *testing.T) {
+	server := newAvalancheOrgServer(t)
+	client := NewNWACClient()
+	client.BaseURL = server.URL
+	client.HTTPClient = server.Client()
+	now := time.Date(2024, 1, 11, 15, 0, 0, 0, time.UTC)
+
+	snoqualmie := MountainCoordinates{MountainID: 50, Lat: 47.4446, Lon: -121.4271}
+	forecast, err := client.Forecast(snoqualmie, now)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 50, forecast.MountainID)
+	assert.Equal(t, "<p>Wind slabs will be most reactive near ridgelines. Avoid steep, wind-loaded slopes near and above treeline.</p>", forecast.AvalancheSummary)
+	assert.Equal(t, time.Date(2024, 1, 11, 1, 27, 0, 0, time.UTC), forecast.IssueDate.UTC())
+	assert.Equal(t, 3, forecast.OverallDangerLevel)
+	assert.Equal(t, "https://nwac.us/avalanche-forecast/#/snoqualmie-pass", forecast.ForecastURL)
+	assert.Equal(t, []AvalancheProblem{
+		{
+			Type:       "Wind Slab",
+			Aspects:    []string{"N", "NE", "E"},
+			Elevations: []string{ElevationAboveTreeline, ElevationNearTreeline},
+			Rose: map[string][]string{
+				ElevationAboveTreeline: {"N", "NE", "E"},
+				ElevationNearTreeline:  {"N", "NE"},
+			},
+			Likelihood:   "Likely",
+			MinSize:      1,
+			MaxSize:      2,
+			TravelAdvice: "<p>Use caution near ridgelines where wind has drifted snow.</p>",
+		},
+		{
+			Type:       "Loose Wet",
+			Aspects:    []string{"S", "SW"},
+			Elevations: []string{ElevationNearTreeline, ElevationBelowTreeline},
+			Rose: map[string][]string{
+				ElevationNearTreeline:  {"S"},
+				ElevationBelowTreeline: {"S", "SW"},
+			},
+			Likelihood:   "Unlikely",
+			MinSize:      1,
+			MaxSize:      1,
+			TravelAdvice: "<p>Roller balls signal a wet snow problem.</p>",
+		},
+	}, forecast.Problems)
+
+	// Expires at 18:00 Pacific on the 11th, so "current" is the 11th
+	expected := []AvalancheDangerLevel{
+		{
+			Date:               "2024-01-11",
+			OverallDangerLevel: 3,
+			AboveTreeline:      AvalancheRating{Level: 3, Rating: "Considerable"},
+			NearTreeline:       AvalancheRating{Level: 3, Rating: "Considerable"},
+			BelowTreeline:      AvalancheRating{Level: 1, Rating: "Low"},
+		},
+		{
+			Date:               "2024-01-12",
+			OverallDangerLevel: 2,
+			AboveTreeline:      AvalancheRating{Level: 2, Rating: "Moderate"},
+			NearTreeline:       AvalancheRating{Level: 2, Rating: "Moderate"},
+			BelowTreeline:      AvalancheRating{Level: 2, Rating: "Moderate"},
+		},
+	}
+	if _, err := time.LoadLocation("America/Los_Angeles"); err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	assert.Equal(t, expected, forecast.DangerLevels)
+
+	t.Run("off season zone", func(t *testing.T) {
+		hood := MountainCoordinates{MountainID: 51, Lat: 45.3311, Lon: -121.7113}
+		_, err := client.Forecast(hood, now)
+		assert.EqualError(t, err, "NWAC zone Mt Hood is off season")
+	})
+}
+
+func TestRatingForLevel(t *testing.T) {
+	assert.Equal(t, AvalancheRating{Level: 4, Rating: "High"}, ratingForLevel(4))
+	assert.Equal(t, AvalancheRating{Level: 0, Rating: "No Rating"}, ratingForLevel(-1))
+	assert.Equal(t, AvalancheRating{Level: 0, Rating: "No Rating"}, ratingForLevel(6))
+}
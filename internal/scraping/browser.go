@@ -0,0 +1,38 @@
+package scraping
+
+import (
+	"context"
+	"log"
+
+	"powderhoundgo/internal/browserpool"
+	"powderhoundgo/internal/fixtures"
+
+	"github.com/chromedp/chromedp"
+)
+
+var browserPool *browserpool.Pool
+
+// UseBrowserPool makes scraping tasks open tabs on a shared pool of browsers
+// instead of launching a new Chrome process for every task
+func UseBrowserPool(pool *browserpool.Pool) {
+	browserPool = pool
+}
+
+func newBrowserContext(parent context.Context) (context.Context, context.CancelFunc, error) {
+	if browserPool == nil {
+		ctx, cancel := chromedp.NewContext(parent, chromedp.WithLogf(log.Printf))
+		return ctx, cancel, nil
+	}
+
+	ctx, cancel, err := browserPool.NewTab(parent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Pooled tabs hang off the browser's context rather than parent, so carry
+	// over the fixture recorder explicitly
+	if recorder, ok := parent.Value(recorderKey{}).(*fixtures.Recorder); ok {
+		ctx = withRecorder(ctx, recorder)
+	}
+	return ctx, cancel, nil
+}
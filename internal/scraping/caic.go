@@ -0,0 +1,258 @@
+package scraping
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// CAIC's website proxies the AVID forecast API, the same one its map reads
+	CAICBaseURL = "https://avalanche.state.co.us/api-proxy/avid"
+
+	caicCenterID            = "CAIC"
+	caicTimezone            = "America/Denver"
+	caicForecastProductType = "avalancheforecast"
+)
+
+// CAICClient fetches forecasts from the CAIC forecast API
+type CAICClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// Colorado's borders
+var coloradoBounds = geoBounds{minLat: 36.99, maxLat: 41.01, minLon: -109.06, maxLon: -102.04}
+
+func NewCAICClient() *CAICClient {
+	return &CAICClient{BaseURL: CAICBaseURL, HTTPClient: httpClient}
+}
+
+func (c *CAICClient) Name() string {
+	return "caic"
+}
+
+func (c *CAICClient) Covers(mountain MountainCoordinates) bool {
+	return coversRegion(mountain, caicCenterID, coloradoBounds)
+}
+
+// A forecast product from /products/all. Other product types (e.g. regional
+// discussions) share the list and are skipped
+type caicProduct struct {
+	ID               string            `json:"id"`
+	Type             string            `json:"type"`
+	AreaID           string            `json:"areaId"`
+	IssueDateTime    string            `json:"issueDateTime"`
+	ExpiryDateTime   string            `json:"expiryDateTime"`
+	AvalancheSummary caicSummary       `json:"avalancheSummary"`
+	DangerRatings    caicDangerRatings `json:"dangerRatings"`
+	// One list of problems per forecast day
+	AvalancheProblems struct {
+		Days [][]caicProblem `json:"days"`
+	} `json:"avalancheProblems"`
+}
+
+type caicSummary struct {
+	Days []struct {
+		Date    string `json:"date"`
+		Content string `json:"content"`
+	} `json:"days"`
+}
+
+type caicDangerRatings struct {
+	Days []caicDangerDay `json:"days"`
+}
+
+type caicDangerDay struct {
+	Position int    `json:"position"`
+	Alp      string `json:"alp"`
+	Tln      string `json:"tln"`
+	Btl      string `json:"btl"`
+	Date     string `json:"date"`
+}
+
+type caicProblem struct {
+	Type string `json:"type"`
+	// e.g. "ne_tln" for northeast aspects near treeline
+	AspectElevations []string `json:"aspectElevations"`
+	Likelihood       string   `json:"likelihood"`
+	ExpectedSize     struct {
+		Min string `json:"min"`
+		Max string `json:"max"`
+	} `json:"expectedSize"`
+	Comment string `json:"comment"`
+}
+
+var caicElevations = map[string]string{
+	"alp": ElevationAboveTreeline,
+	"tln": ElevationNearTreeline,
+	"btl": ElevationBelowTreeline,
+}
+
+func mapCAICProblem(problem caicProblem) AvalancheProblem {
+	mapped := AvalancheProblem{
+		Type:         titleCase(problem.Type),
+		Likelihood:   titleCase(problem.Likelihood),
+		MinSize:      parseProblemSize(problem.ExpectedSize.Min),
+		MaxSize:      parseProblemSize(problem.ExpectedSize.Max),
+		TravelAdvice: strings.TrimSpace(problem.Comment),
+	}
+	var locations []aspectElevation
+	for _, value := range problem.AspectElevations {
+		aspect, elevation, ok := strings.Cut(value, "_")
+		if !ok {
+			continue
+		}
+		locations = append(locations, aspectElevation{strings.ToUpper(aspect), caicElevations[elevation]})
+	}
+	mapped.setLocations(locations)
+	return mapped
+}
+
+// The API names ratings in camel case, the levels follow the North American
+// danger scale
+var caicRatings = map[string]AvalancheRating{
+	"noRating":     {Level: 0, Rating: "No Rating"},
+	"low":          {Level: 1, Rating: "Low"},
+	"moderate":     {Level: 2, Rating: "Moderate"},
+	"considerable": {Level: 3, Rating: "Considerable"},
+	"high":         {Level: 4, Rating: "High"},
+	"extreme":      {Level: 5, Rating: "Extreme"},
+}
+
+func parseCAICRating(rating string) AvalancheRating {
+	if parsed, ok := caicRatings[rating]; ok {
+		return parsed
+	}
+	return caicRatings["noRating"]
+}
+
+// Forecast returns the forecast in effect at now for the zone the mountain
+// falls in
+func (c *CAICClient) Forecast(mountain MountainCoordinates, now time.Time) (*AvalancheForecast, error) {
+	areas, err := c.areas(now)
+	if err != nil {
+		return nil, err
+	}
+	areaID, err := findAreaID(areas, mountain.Lat, mountain.Lon)
+	if err != nil {
+		return nil, err
+	}
+
+	var products []caicProduct
+	if err := c.get(fmt.Sprintf("/products/all?datetime=%s&includeExpired=true", now.UTC().Format(time.RFC3339)), &products); err != nil {
+		return nil, err
+	}
+	product, ok := latestForecastProduct(products, areaID)
+	if !ok {
+		return nil, fmt.Errorf("no CAIC forecast for area %s", areaID)
+	}
+
+	forecast, err := mapCAICProduct(product)
+	if err != nil {
+		return nil, err
+	}
+	forecast.MountainID = mountain.MountainID
+	forecast.ForecastURL = avalancheForecastURL(mountain)
+	forecast.UpdatedAt = now
+	return forecast, nil
+}
+
+// The forecast areas in effect at now. CAIC redraws them as conditions change
+func (c *CAICClient) areas(now time.Time) (geoJSONFeatureCollection, error) {
+	var areas geoJSONFeatureCollection
+	err := c.get(fmt.Sprintf("/products/all/area?productType=%s&datetime=%s&includeExpired=true", caicForecastProductType, now.UTC().Format(time.RFC3339)), &areas)
+	return areas, err
+}
+
+// Requests go through the proxy with the API path in _api_proxy_uri
+func (c *CAICClient) get(uri string, v interface{}) error {
+	return getJSON(c.HTTPClient, fmt.Sprintf("%s?_api_proxy_uri=%s", c.BaseURL, url.QueryEscape(uri)), v)
+}
+
+func findAreaID(areas geoJSONFeatureCollection, lat, lon float64) (string, error) {
+	for _, feature := range areas.Features {
+		inside, err := feature.Geometry.contains(lat, lon)
+		if err != nil {
+			log.Printf("Warning: skipping forecast area %s: %v", feature.featureID(), err)
+			continue
+		}
+		if inside {
+			return feature.featureID(), nil
+		}
+	}
+	return "", fmt.Errorf("no CAIC forecast area contains %f,%f", lat, lon)
+}
+
+// Expired forecasts are included so a zone still has one between the
+// previous forecast expiring and the next being published; the latest issued
+// wins
+func latestForecastProduct(products []caicProduct, areaID string) (caicProduct, bool) {
+	var latest caicProduct
+	found := false
+	for _, product := range products {
+		if product.Type != caicForecastProductType || product.AreaID != areaID {
+			continue
+		}
+		// RFC 3339 timestamps in UTC sort as strings
+		if !found || product.IssueDateTime > latest.IssueDateTime {
+			latest = product
+			found = true
+		}
+	}
+	return latest, found
+}
+
+func mapCAICProduct(product caicProduct) (*AvalancheForecast, error) {
+	issueDate, err := parseIssueDate(product.IssueDateTime, loadLocation(caicTimezone))
+	if err != nil {
+		return nil, err
+	}
+
+	var summary string
+	if len(product.AvalancheSummary.Days) > 0 {
+		summary = strings.TrimSpace(product.AvalancheSummary.Days[0].Content)
+	}
+
+	days := append([]caicDangerDay(nil), product.DangerRatings.Days...)
+	sort.SliceStable(days, func(i, j int) bool { return days[i].Position < days[j].Position })
+
+	// Every published day is kept, including the outlook days after tomorrow
+	var dangerLevels []AvalancheDangerLevel
+	for _, day := range days {
+		dangerLevels = append(dangerLevels, newDangerLevel(
+			caicDate(day.Date),
+			parseCAICRating(day.Alp),
+			parseCAICRating(day.Tln),
+			parseCAICRating(day.Btl),
+		))
+	}
+
+	// Problems are forecast for today only
+	var problems []AvalancheProblem
+	if len(product.AvalancheProblems.Days) > 0 {
+		for _, problem := range product.AvalancheProblems.Days[0] {
+			problems = append(problems, mapCAICProblem(problem))
+		}
+	}
+
+	return &AvalancheForecast{
+		AvalancheSummary:   summary,
+		IssueDate:          issueDate,
+		OverallDangerLevel: overallDangerLevel(dangerLevels),
+		DangerLevels:       dangerLevels,
+		Problems:           problems,
+	}, nil
+}
+
+// Danger days are dated at midnight, keep just the calendar date
+func caicDate(date string) string {
+	if len(date) >= len("2006-01-02") {
+		return date[:len("2006-01-02")]
+	}
+	return date
+}
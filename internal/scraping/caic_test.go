@@ -0,0 +1,235 @@
+package scraping
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Serves the recorded CAIC responses by API path, the way the site's proxy does
+func newCAICServer(t *testing.T) (*httptest.Server, *[]string) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uri := r.URL.Query().Get("_api_proxy_uri")
+		requested = append(requested, uri)
+		switch {
+		case strings.HasPrefix(uri, "/products/all/area?"):
+			http.ServeFile(w, r, filepath.Join("testdata", "caic", "areas.json"))
+		case strings.HasPrefix(uri, "/products/all?"):
+			http.ServeFile(w, r, filepath.Join("testdata", "caic", "products.json"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requested
+}
+
+func TestCAICForecast(t *testing.T) {
+	server, requested := newCAICServer(t)
+	client := &CAICClient{BaseURL: server.URL, HTTPClient: server.Client()}
+	now := time.Date(2024, 1, 11, 6, 0, 0, 0, time.UTC)
+
+	vail := MountainCoordinates{MountainID: 13, Lat: 39.6403, Lon: -106.3742}
+	forecast, err := client.Forecast(vail, now)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 13, forecast.MountainID)
+	assert.Equal(t, "<p>Strong winds are drifting new snow onto <strong>north and east</strong> facing slopes.</p><p>Avoid wind-loaded terrain near and above treeline.</p>", forecast.AvalancheSummary)
+	assert.Equal(t, time.Date(2024, 1, 10, 23, 0, 0, 0, time.UTC), forecast.IssueDate.UTC(), "the latest issued forecast wins")
+	assert.Equal(t, 4, forecast.OverallDangerLevel)
+	assert.Equal(t, []AvalancheDangerLevel{
+		{
+			Date:               "2024-01-11",
+			OverallDangerLevel: 4,
+			AboveTreeline:      AvalancheRating{Level: 4, Rating: "High"},
+			NearTreeline:       AvalancheRating{Level: 3, Rating: "Considerable"},
+			BelowTreeline:      AvalancheRating{Level: 2, Rating: "Moderate"},
+		},
+		{
+			Date:               "2024-01-12",
+			OverallDangerLevel: 3,
+			AboveTreeline:      AvalancheRating{Level: 3, Rating: "Considerable"},
+			NearTreeline:       AvalancheRating{Level: 2, Rating: "Moderate"},
+			BelowTreeline:      AvalancheRating{Level: 2, Rating: "Moderate"},
+		},
+		{
+			Date:               "2024-01-13",
+			OverallDangerLevel: 2,
+			AboveTreeline:      AvalancheRating{Level: 2, Rating: "Moderate"},
+			NearTreeline:       AvalancheRating{Level: 2, Rating: "Moderate"},
+			BelowTreeline:      AvalancheRating{Level: 1, Rating: "Low"},
+		},
+	}, forecast.DangerLevels, "the third day's outlook is kept")
+	assert.Equal(t, []AvalancheProblem{
+		{
+			Type:       "Wind Slab",
+			Aspects:    []string{"N", "NE", "E"},
+			Elevations: []string{ElevationAboveTreeline, ElevationNearTreeline},
+			Rose: map[string][]string{
+				ElevationAboveTreeline: {"N", "NE", "E"},
+				ElevationNearTreeline:  {"N", "NE"},
+			},
+			Likelihood:   "Very Likely",
+			MinSize:      1,
+			MaxSize:      2,
+			TravelAdvice: "<p>Avoid slopes with fresh drifts.</p>",
+		},
+		{
+			Type:       "Persistent Slab",
+			Aspects:    []string{"N", "NW"},
+			Elevations: []string{ElevationAboveTreeline, ElevationNearTreeline, ElevationBelowTreeline},
+			Rose: map[string][]string{
+				ElevationAboveTreeline: {"N", "NW"},
+				ElevationNearTreeline:  {"N", "NW"},
+				ElevationBelowTreeline: {"N"},
+			},
+			Likelihood:   "Possible",
+			MinSize:      1.5,
+			MaxSize:      2.5,
+			TravelAdvice: "<p>Stay off steep northerly slopes with a shallow snowpack.</p>",
+		},
+	}, forecast.Problems, "only today's problems are kept")
+	assert.Equal(t, "https://avalanche.state.co.us/?lat=39.640300&lng=-106.374200", forecast.ForecastURL)
+	assert.Equal(t, now, forecast.UpdatedAt)
+
+	assert.Equal(t, []string{
+		"/products/all/area?productType=avalancheforecast&datetime=2024-01-11T06:00:00Z&includeExpired=true",
+		"/products/all?datetime=2024-01-11T06:00:00Z&includeExpired=true",
+	}, *requested)
+}
+
+func TestCAICForecastMissingRatings(t *testing.T) {
+	server, _ := newCAICServer(t)
+	client := &CAICClient{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	eldora := MountainCoordinates{MountainID: 6, Lat: 39.9372, Lon: -105.5827}
+	forecast, err := client.Forecast(eldora, time.Now())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, forecast.OverallDangerLevel)
+	assert.Equal(t, AvalancheRating{Level: 0, Rating: "No Rating"}, forecast.DangerLevels[0].BelowTreeline)
+	assert.Equal(t, AvalancheRating{Level: 0, Rating: "No Rating"}, forecast.DangerLevels[1].BelowTreeline)
+	assert.Empty(t, forecast.Problems)
+}
+
+func TestCAICForecastErrors(t *testing.T) {
+	server, _ := newCAICServer(t)
+	client := &CAICClient{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	t.Run("outside every forecast area", func(t *testing.T) {
+		_, err := client.Forecast(MountainCoordinates{MountainID: 1, Lat: 44.5, Lon: -110.5}, time.Now())
+		assert.ErrorContains(t, err, "no CAIC forecast area contains")
+	})
+
+	t.Run("area without a forecast", func(t *testing.T) {
+		aspen := MountainCoordinates{MountainID: 2, Lat: 39.05, Lon: -107.1}
+		_, err := client.Forecast(aspen, time.Now())
+		assert.EqualError(t, err, "no CAIC forecast for area e1a8c3f5d7b9024e6a1c3b5d7f9e0a2c")
+	})
+
+	t.Run("API unavailable", func(t *testing.T) {
+		down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		}))
+		defer down.Close()
+		client := &CAICClient{BaseURL: down.URL, HTTPClient: down.Client()}
+		_, err := client.Forecast(MountainCoordinates{Lat: 39.6403, Lon: -106.3742}, time.Now())
+		assert.ErrorContains(t, err, "unexpected status 502")
+	})
+}
+
+func TestFindAreaID(t *testing.T) {
+	var areas geoJSONFeatureCollection
+	server, _ := newCAICServer(t)
+	client := &CAICClient{BaseURL: server.URL, HTTPClient: server.Client()}
+	if err := client.get("/products/all/area?productType=avalancheforecast", &areas); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		expected string
+	}{
+		{"polygon", 39.6403, -106.3742, "4f3b1a12c9e1d0a2f7c4e8b95d6a3e21"},
+		{"multipolygon", 39.05, -107.1, "e1a8c3f5d7b9024e6a1c3b5d7f9e0a2c"},
+		{"second polygon of a multipolygon", 38.9, -107.4, "e1a8c3f5d7b9024e6a1c3b5d7f9e0a2c"},
+		{"hole in a multipolygon", 39.15, -106.9, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, _ := findAreaID(areas, test.lat, test.lon)
+			assert.Equal(t, test.expected, id)
+		})
+	}
+}
+
+func TestParseCAICRating(t *testing.T) {
+	assert.Equal(t, AvalancheRating{Level: 3, Rating: "Considerable"}, parseCAICRating("considerable"))
+	assert.Equal(t, AvalancheRating{Level: 5, Rating: "Extreme"}, parseCAICRating("extreme"))
+	assert.Equal(t, AvalancheRating{Level: 0, Rating: "No Rating"}, parseCAICRating("noRating"))
+	assert.Equal(t, AvalancheRating{Level: 0, Rating: "No Rating"}, parseCAICRating("unknown"))
+}
+
+func TestParseIssueDate(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	expected := time.Date(2024, 1, 10, 16, 0, 0, 0, denver)
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"RFC 3339", "2024-01-10T23:00:00Z"},
+		{"local time without an offset", "2024-01-10T16:00:00"},
+		{"page text", "Issued: 4:00 PM Wed, Jan 10, 2024"},
+		{"page text with the date first", " Wed, Jan 10, 2024 4:00 PM "},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issueDate, err := parseIssueDate(test.text, denver)
+			assert.NoError(t, err)
+			assert.True(t, expected.Equal(issueDate), "got %s", issueDate)
+			assert.Equal(t, denver, issueDate.Location())
+		})
+	}
+
+	_, err = parseIssueDate("Issued yesterday", denver)
+	assert.EqualError(t, err, `unrecognized issue date "yesterday"`)
+}
+
+func TestTreeLineSelectors(t *testing.T) {
+	// The first two days match the selectors the page scraper used to hard-code
+	assert.Equal(t, [3]string{
+		"div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(1) > div:nth-child(2) > p:nth-child(1) > b:nth-child(1)",
+		"div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(2) > div:nth-child(2) > p:nth-child(1) > b:nth-child(1)",
+		"div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(3) > div:nth-child(2) > p:nth-child(1) > b:nth-child(1)",
+	}, treeLineSelectors(1))
+	assert.Equal(t, "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(2) > div:nth-child(2) > div:nth-child(1) > div:nth-child(1) > p:nth-child(1) > b:nth-child(1)", treeLineSelectors(2)[0])
+	assert.Equal(t, "div.mt-4:nth-child(1) > div:nth-child(1) > div:nth-child(3) > div:nth-child(2) > div:nth-child(3) > div:nth-child(1) > p:nth-child(1) > b:nth-child(1)", treeLineSelectors(3)[2])
+}
+
+func TestOverallDangerLevel(t *testing.T) {
+	assert.Equal(t, 0, overallDangerLevel(nil))
+
+	days := []AvalancheDangerLevel{
+		newDangerLevel("2024-01-11", parseTreeLineData("2-Moderate"), parseTreeLineData("3-Considerable"), parseTreeLineData("1-Low")),
+		newDangerLevel("2024-01-12", parseTreeLineData("4-High"), parseTreeLineData(""), parseTreeLineData("2-Moderate")),
+	}
+	assert.Equal(t, 3, days[0].OverallDangerLevel)
+	assert.Equal(t, 4, days[1].OverallDangerLevel)
+	assert.Equal(t, 3, overallDangerLevel(days), "today's level, not the outlook's")
+}
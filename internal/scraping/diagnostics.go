@@ -0,0 +1,142 @@
+package scraping
+
+import (
+	"strconv"
+	"strings"
+
+	"powderhoundgo/internal/supabase"
+)
+
+const (
+	// Consecutive runs, counting the current one, a field has to come back
+	// empty or defaulted before the resort is flagged as drifting
+	DriftRuns = 3
+	// Past runs loaded when checking a resort for drift
+	DriftHistory = 24
+)
+
+// Open lift and run totals read from the terrain page
+type terrainCounts struct {
+	runsOpen    int
+	liftsOpen   int
+	diagnostics []supabase.FieldDiagnostic
+}
+
+// Records a field's diagnostic, replacing an earlier one for the same field
+func (c *terrainCounts) record(diagnostic supabase.FieldDiagnostic) {
+	for i, existing := range c.diagnostics {
+		if existing.Field == diagnostic.Field {
+			c.diagnostics[i] = diagnostic
+			return
+		}
+	}
+	c.diagnostics = append(c.diagnostics, diagnostic)
+}
+
+// Converts the runs and lifts open text read from each terrain node into
+// totals. Lifts default to 0 when no node has them
+func convertTerrainTexts(config supabase.ScrapingConfig, runsOpenTexts, liftsOpenTexts []string) (terrainCounts, error) {
+	var counts terrainCounts
+
+	runsOpen, err := combineRunsOpen(config, runsOpenTexts)
+	counts.record(terrainTextDiagnostic("runsOpen", config.Terrain.RunsOpenSelector, runsOpenTexts, runsOpen, err))
+	if err != nil {
+		return counts, err
+	}
+	counts.runsOpen = runsOpen
+
+	// The last node with a lift total wins
+	liftsOpenText := "0"
+	if n := len(liftsOpenTexts); n > 0 {
+		liftsOpenTexts = liftsOpenTexts[n-1:]
+		liftsOpenText = liftsOpenTexts[0]
+	}
+	liftsOpen, err := convertTerrainCount(liftsOpenText)
+	counts.record(terrainTextDiagnostic("liftsOpen", config.Terrain.LiftsOpenSelector, liftsOpenTexts, liftsOpen, err))
+	if err != nil {
+		return counts, err
+	}
+	counts.liftsOpen = liftsOpen
+	return counts, nil
+}
+
+func terrainTextDiagnostic(field, selector string, texts []string, value int, err error) supabase.FieldDiagnostic {
+	diagnostic := supabase.FieldDiagnostic{
+		Field:   field,
+		RawText: strings.Join(texts, " | "),
+		Value:   value,
+		Status:  supabase.FieldResolved,
+	}
+	switch {
+	case len(texts) == 0:
+		diagnostic.Status = supabase.FieldEmpty
+	case err != nil || allCountsDefault(texts):
+		diagnostic.Selector = selector
+		diagnostic.Value = 0
+		diagnostic.Status = supabase.FieldDefaulted
+	default:
+		diagnostic.Selector = selector
+	}
+	return diagnostic
+}
+
+func allCountsDefault(texts []string) bool {
+	for _, text := range texts {
+		if !countDefaults(text) {
+			return false
+		}
+	}
+	return true
+}
+
+// No matching status elements can mean a closed resort as well as a selector
+// that stopped matching, so a count of 0 is reported as empty and only flags
+// drift once it lasts DriftRuns runs after the field last resolved
+func countDiagnostic(field, selector string, count int) supabase.FieldDiagnostic {
+	diagnostic := supabase.FieldDiagnostic{
+		Field:    field,
+		Selector: selector,
+		RawText:  strconv.Itoa(count) + " matching elements",
+		Value:    count,
+		Status:   supabase.FieldResolved,
+	}
+	if count == 0 {
+		diagnostic.Status = supabase.FieldEmpty
+	}
+	return diagnostic
+}
+
+// DetectSelectorDrift returns the fields that resolved in an earlier run but
+// have come back empty or defaulted to 0 for at least runs runs in a row,
+// counting the current one. history holds earlier runs, newest first. Runs
+// that didn't record a field, e.g. because the scrape failed before reaching
+// it, are skipped
+func DetectSelectorDrift(current supabase.ScrapeDiagnostics, history []supabase.ScrapeDiagnostics, runs int) []string {
+	newestFirst := append([]supabase.ScrapeDiagnostics{current}, history...)
+
+	var drifting []string
+	for _, field := range current.Fields {
+		if field.Status == supabase.FieldResolved {
+			continue
+		}
+
+		failedRuns := 0
+		resolvedBefore := false
+		for _, run := range newestFirst {
+			status, ok := run.Status(field.Field)
+			if !ok {
+				continue
+			}
+			if status == supabase.FieldResolved {
+				resolvedBefore = true
+				break
+			}
+			failedRuns++
+		}
+
+		if resolvedBefore && failedRuns >= runs {
+			drifting = append(drifting, field.Field)
+		}
+	}
+	return drifting
+}
@@ -0,0 +1,142 @@
+package scraping
+
+import (
+	"testing"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runWith(statuses map[string]string) supabase.ScrapeDiagnostics {
+	var run supabase.ScrapeDiagnostics
+	for _, field := range []string{"baseDepth", "snow24", "liftsOpen"} {
+		if status, ok := statuses[field]; ok {
+			run.Fields = append(run.Fields, supabase.FieldDiagnostic{Field: field, Status: status})
+		}
+	}
+	return run
+}
+
+func TestDetectSelectorDrift(t *testing.T) {
+	resolved := runWith(map[string]string{
+		"baseDepth": supabase.FieldResolved,
+		"snow24":    supabase.FieldResolved,
+		"liftsOpen": supabase.FieldResolved,
+	})
+	baseEmpty := runWith(map[string]string{
+		"baseDepth": supabase.FieldEmpty,
+		"snow24":    supabase.FieldResolved,
+		"liftsOpen": supabase.FieldResolved,
+	})
+	liftsDefaulted := runWith(map[string]string{
+		"baseDepth": supabase.FieldResolved,
+		"snow24":    supabase.FieldResolved,
+		"liftsOpen": supabase.FieldDefaulted,
+	})
+
+	tests := []struct {
+		name    string
+		current supabase.ScrapeDiagnostics
+		history []supabase.ScrapeDiagnostics
+		want    []string
+	}{
+		{"everything resolves", resolved, []supabase.ScrapeDiagnostics{resolved, resolved}, nil},
+		{"empty for three runs after resolving", baseEmpty,
+			[]supabase.ScrapeDiagnostics{baseEmpty, baseEmpty, resolved}, []string{"baseDepth"}},
+		{"defaulted for three runs after resolving", liftsDefaulted,
+			[]supabase.ScrapeDiagnostics{liftsDefaulted, liftsDefaulted, resolved}, []string{"liftsOpen"}},
+		{"not enough failed runs yet", baseEmpty,
+			[]supabase.ScrapeDiagnostics{baseEmpty, resolved}, nil},
+		{"never resolved", baseEmpty,
+			[]supabase.ScrapeDiagnostics{baseEmpty, baseEmpty, baseEmpty}, nil},
+		{"runs without the field are skipped", baseEmpty,
+			[]supabase.ScrapeDiagnostics{{}, baseEmpty, {}, baseEmpty, resolved}, []string{"baseDepth"}},
+		{"mixed failures count towards the streak", baseEmpty,
+			[]supabase.ScrapeDiagnostics{runWith(map[string]string{"baseDepth": supabase.FieldDefaulted}), baseEmpty, resolved}, []string{"baseDepth"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectSelectorDrift(tt.current, tt.history, 3))
+		})
+	}
+}
+
+func TestConvertTerrainTextsDiagnostics(t *testing.T) {
+	config := supabase.ScrapingConfig{
+		Terrain: supabase.TerrainConfig{
+			SumRunsFromMultipleSources: true,
+			RunsOpenSelector:           ".runs",
+			LiftsOpenSelector:          ".lifts",
+		},
+	}
+
+	counts, err := convertTerrainTexts(config, []string{"41 of 68", "12 of 20"}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 53, counts.runsOpen)
+	assert.Equal(t, []supabase.FieldDiagnostic{
+		{Field: "runsOpen", Selector: ".runs", RawText: "41 of 68 | 12 of 20", Value: 53, Status: supabase.FieldResolved},
+		{Field: "liftsOpen", Value: 0, Status: supabase.FieldEmpty},
+	}, counts.diagnostics)
+
+	t.Run("last lift total wins", func(t *testing.T) {
+		counts, err := convertTerrainTexts(config, nil, []string{"8 of 10", "3/4"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, 3, counts.liftsOpen)
+			assert.Contains(t, counts.diagnostics, supabase.FieldDiagnostic{
+				Field: "liftsOpen", Selector: ".lifts", RawText: "3/4", Value: 3, Status: supabase.FieldResolved,
+			})
+		}
+	})
+}
+
+func TestPlaceholderDiagnostics(t *testing.T) {
+	t.Run("placeholder terrain counts are defaulted", func(t *testing.T) {
+		config := supabase.ScrapingConfig{Terrain: supabase.TerrainConfig{RunsOpenSelector: ".runs", LiftsOpenSelector: ".lifts"}}
+		counts, err := convertTerrainTexts(config, []string{"--"}, []string{"—"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []supabase.FieldDiagnostic{
+			{Field: "runsOpen", Selector: ".runs", RawText: "--", Value: 0, Status: supabase.FieldDefaulted},
+			{Field: "liftsOpen", Selector: ".lifts", RawText: "—", Value: 0, Status: supabase.FieldDefaulted},
+		}, counts.diagnostics)
+	})
+
+	t.Run("placeholder measurements are defaulted", func(t *testing.T) {
+		config := supabase.ScrapingConfig{
+			Conditions: supabase.ConditionsConfig{
+				BaseDepthSelector: supabase.NewFieldSelector(".base"),
+				Snow24Selector:    supabase.NewFieldSelector(".24h"),
+				Snow48Selector:    supabase.NewFieldSelector(".48h"),
+			},
+		}
+		texts := newConditionsText(pageFields(config))
+		for _, field := range texts.fields {
+			text := map[string]string{"baseDepth": "42", "snow24": "--", "snow48": "—"}[field.name]
+			texts.set(field, text, field.selector.Selectors[0])
+		}
+		report, err := texts.report("")
+		if !assert.NoError(t, err) {
+			return
+		}
+		statuses := map[string]string{}
+		for _, field := range report.diagnostics.Fields {
+			statuses[field.Field] = field.Status
+		}
+		assert.Equal(t, supabase.FieldResolved, statuses["baseDepth"])
+		assert.Equal(t, supabase.FieldDefaulted, statuses["snow24"])
+		assert.Equal(t, supabase.FieldDefaulted, statuses["snow48"])
+	})
+}
+
+func TestCountDiagnostic(t *testing.T) {
+	assert.Equal(t, supabase.FieldResolved, countDiagnostic("liftsOpen", ".lift.open", 4).Status)
+
+	empty := countDiagnostic("liftsOpen", ".lift.open", 0)
+	assert.Equal(t, supabase.FieldEmpty, empty.Status)
+	assert.Equal(t, "0 matching elements", empty.RawText)
+}
@@ -0,0 +1,188 @@
+package scraping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"powderhoundgo/internal/artifacts"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+var artifactStore artifacts.Store
+
+// UseArtifactStore makes failed scrapes save a screenshot, the DOM and the
+// browser's console and network errors to store. Without a store failures
+// only report their error
+func UseArtifactStore(store artifacts.Store) {
+	artifactStore = store
+}
+
+// ScrapeError is returned when a scrape fails after its page was loaded,
+// listing the keys of the artifacts captured from the page
+type ScrapeError struct {
+	Err       error
+	Artifacts []string
+}
+
+func (e *ScrapeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ScrapeError) Unwrap() error {
+	return e.Err
+}
+
+// FailureArtifacts returns the artifact keys attached to a scrape error, if any
+func FailureArtifacts(err error) []string {
+	var scrapeErr *ScrapeError
+	if errors.As(err, &scrapeErr) {
+		return scrapeErr.Artifacts
+	}
+	return nil
+}
+
+// Console messages, uncaught exceptions and failed requests seen by a tab
+type browserLog struct {
+	mu       sync.Mutex
+	lines    []string
+	requests map[network.RequestID]string
+}
+
+func (l *browserLog) add(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, time.Now().UTC().Format(time.RFC3339)+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *browserLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
+
+// Starts collecting the browser log of the tab in ctx. Must be called before
+// the tab navigates so requests made while loading are included
+func listenBrowserLog(ctx context.Context) *browserLog {
+	l := &browserLog{requests: map[network.RequestID]string{}}
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch ev := ev.(type) {
+		case *runtime.EventConsoleAPICalled:
+			var args []string
+			for _, arg := range ev.Args {
+				if arg.Value != nil {
+					args = append(args, string(arg.Value))
+				} else {
+					args = append(args, arg.Description)
+				}
+			}
+			l.add("console.%s: %s", ev.Type, strings.Join(args, " "))
+		case *runtime.EventExceptionThrown:
+			details := ev.ExceptionDetails
+			if details.Exception != nil && details.Exception.Description != "" {
+				l.add("exception: %s", details.Exception.Description)
+			} else {
+				l.add("exception: %s", details.Text)
+			}
+		case *network.EventRequestWillBeSent:
+			l.mu.Lock()
+			l.requests[ev.RequestID] = ev.Request.URL
+			l.mu.Unlock()
+		case *network.EventResponseReceived:
+			if ev.Response.Status >= 400 {
+				l.add("network: %d %s", ev.Response.Status, ev.Response.URL)
+			}
+		case *network.EventLoadingFailed:
+			l.mu.Lock()
+			url := l.requests[ev.RequestID]
+			l.mu.Unlock()
+			l.add("network: %s failed: %s", url, ev.ErrorText)
+		}
+	})
+	return l
+}
+
+type artifact struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+var unsafeKeyCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Artifacts from one failure share a prefix, e.g. "steamboat/20240101T060000Z"
+func artifactPrefix(name string) string {
+	slug := strings.Trim(unsafeKeyCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	return slug + "/" + time.Now().UTC().Format("20060102T150405Z")
+}
+
+// Saves the artifacts to the store and wraps err in a ScrapeError listing
+// the keys that were saved. Artifacts that fail to save are logged and skipped
+func saveArtifacts(name string, files []artifact, err error) error {
+	prefix := artifactPrefix(name)
+	var keys []string
+	for _, file := range files {
+		if len(file.data) == 0 {
+			continue
+		}
+		key := prefix + "/" + file.name
+		if saveErr := artifactStore.Save(key, file.contentType, file.data); saveErr != nil {
+			log.Printf("Warning: failed to save %s: %v", key, saveErr)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return &ScrapeError{Err: err, Artifacts: keys}
+}
+
+// Captures a full-page screenshot, the DOM and the browser log of the tab in
+// ctx after a failed scrape. ctx should be the tab's own context rather than
+// the scrape's timeout, which may already have expired
+func captureBrowserFailure(ctx context.Context, name string, browserLog *browserLog, err error) error {
+	if artifactStore == nil {
+		return err
+	}
+	log.Printf("Capturing failure artifacts for %s", name)
+
+	tctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	var screenshot []byte
+	if captureErr := chromedp.Run(tctx, chromedp.FullScreenshot(&screenshot, 90)); captureErr != nil {
+		log.Printf("Warning: failed to capture screenshot: %v", captureErr)
+	}
+	var dom string
+	if captureErr := chromedp.Run(tctx, chromedp.OuterHTML("html", &dom, chromedp.ByQuery)); captureErr != nil {
+		log.Printf("Warning: failed to capture DOM: %v", captureErr)
+	}
+
+	return saveArtifacts(name, []artifact{
+		{"screenshot.jpg", "image/jpeg", screenshot},
+		{"dom.html", "text/html", []byte(dom)},
+		{"console.log", "text/plain", []byte(browserLog.String())},
+	}, err)
+}
+
+// http engine counterpart of captureBrowserFailure. Only the fetched markup
+// is available without a browser
+func captureDocumentFailure(name string, doc *goquery.Document, err error) error {
+	if artifactStore == nil || doc == nil {
+		return err
+	}
+	dom, htmlErr := doc.Html()
+	if htmlErr != nil {
+		log.Printf("Warning: failed to serialize DOM: %v", htmlErr)
+	}
+	return saveArtifacts(name, []artifact{
+		{"dom.html", "text/html", []byte(dom)},
+	}, err)
+}
@@ -0,0 +1,68 @@
+package scraping
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"powderhoundgo/internal/fixtures"
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryStore map[string][]byte
+
+func (s memoryStore) Save(key string, contentType string, data []byte) error {
+	s[key] = data
+	return nil
+}
+
+func useMemoryStore(t *testing.T) memoryStore {
+	t.Helper()
+	store := memoryStore{}
+	UseArtifactStore(store)
+	t.Cleanup(func() { UseArtifactStore(nil) })
+	return store
+}
+
+func TestArtifactPrefix(t *testing.T) {
+	prefix := artifactPrefix("Arapahoe Basin (A-Basin)")
+	assert.True(t, strings.HasPrefix(prefix, "arapahoe-basin-a-basin/"), prefix)
+}
+
+func TestFailureArtifacts(t *testing.T) {
+	err := fmt.Errorf("failed to scrape vail: %w", &ScrapeError{
+		Err:       errors.New("no value found for base depth"),
+		Artifacts: []string{"vail/20240101T060000Z/dom.html"},
+	})
+	assert.Equal(t, []string{"vail/20240101T060000Z/dom.html"}, FailureArtifacts(err))
+	assert.Nil(t, FailureArtifacts(errors.New("failed to fetch")))
+}
+
+func TestHTTPFailureCapturesDOM(t *testing.T) {
+	store := useMemoryStore(t)
+
+	server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, "fallback-selectors"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	config := fallbackSelectorsConfig(server)
+	config.Conditions.BaseDepthSelector = supabase.NewFieldSelector(".base-depth")
+	_, _, err = scrapeResortConditionsHTTP(config)
+	if !assert.Error(t, err) {
+		return
+	}
+
+	keys := FailureArtifacts(err)
+	if assert.Len(t, keys, 1) {
+		assert.True(t, strings.HasPrefix(keys[0], "fallback-selectors-resort/"), keys[0])
+		assert.True(t, strings.HasSuffix(keys[0], "/dom.html"), keys[0])
+		assert.Contains(t, string(store[keys[0]]), "data-stat")
+	}
+	assert.Contains(t, err.Error(), "base depth")
+}
@@ -0,0 +1,203 @@
+package scraping
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+)
+
+type fieldKind int
+
+const (
+	requiredMeasurement fieldKind = iota
+	optionalMeasurement
+	textValue
+)
+
+// A conditions value and where to read it from: page selectors for the
+// browser and http engines, a JSONPath for the json engine
+type conditionsField struct {
+	// Key in matchedSelectors and the scrape diagnostics, named after the config field
+	name         string
+	propertyName string
+	kind         fieldKind
+	selector     supabase.FieldSelector
+}
+
+func pageFields(config supabase.ScrapingConfig) []conditionsField {
+	conditions := config.Conditions
+	return configuredFields([]conditionsField{
+		{"baseDepth", "base depth", requiredMeasurement, conditions.BaseDepthSelector},
+		{"snow24", "snow past 24 hours", requiredMeasurement, conditions.Snow24Selector},
+		{"snow48", "snow past 48 hours", requiredMeasurement, conditions.Snow48Selector},
+		{"snow7Day", "snow past 7 days", optionalMeasurement, conditions.Snow7DaySelector},
+		{"seasonTotal", "season total", optionalMeasurement, conditions.SeasonTotalSelector},
+		{"snowpack", "snowpack", textValue, conditions.SnowpackSelector},
+	})
+}
+
+func apiFields(config supabase.ScrapingConfig) []conditionsField {
+	api := config.API
+	return configuredFields([]conditionsField{
+		{"baseDepth", "base depth", requiredMeasurement, supabase.NewFieldSelector(api.BaseDepth)},
+		{"snow24", "snow past 24 hours", requiredMeasurement, supabase.NewFieldSelector(api.Snow24)},
+		{"snow48", "snow past 48 hours", requiredMeasurement, supabase.NewFieldSelector(api.Snow48)},
+		{"snow7Day", "snow past 7 days", optionalMeasurement, supabase.NewFieldSelector(api.Snow7Day)},
+		{"seasonTotal", "season total", optionalMeasurement, supabase.NewFieldSelector(api.SeasonTotal)},
+		{"snowpack", "snowpack", textValue, supabase.NewFieldSelector(api.Snowpack)},
+	})
+}
+
+// Drops the optional fields the config doesn't map. Required fields are kept
+// so a missing mapping fails the scrape
+func configuredFields(fields []conditionsField) []conditionsField {
+	var configured []conditionsField
+	for _, field := range fields {
+		if field.kind == requiredMeasurement || field.selector.IsSet() {
+			configured = append(configured, field)
+		}
+	}
+	return configured
+}
+
+// Raw text of each conditions value, collected across the conditions nodes
+// along with the selector each value was read from
+type conditionsText struct {
+	fields           []conditionsField
+	text             map[string]string
+	matchedSelectors map[string]string
+}
+
+func newConditionsText(fields []conditionsField) *conditionsText {
+	return &conditionsText{fields: fields, text: map[string]string{}}
+}
+
+func (t *conditionsText) set(field conditionsField, text, selector string) {
+	if selector != field.selector.Selectors[0] {
+		log.Printf("Warning: %s matched fallback selector %q", field.name, selector)
+	}
+	if t.matchedSelectors == nil {
+		t.matchedSelectors = map[string]string{}
+	}
+	t.text[field.name] = text
+	t.matchedSelectors[field.name] = selector
+}
+
+// Converts the collected text into a report, failing when one of the required
+// values is missing or not a measurement. Optional measurements that don't
+// parse default to 0. The report's diagnostics cover every field even when
+// the conversion fails
+func (t *conditionsText) report(unit string) (resortReport, error) {
+	report := resortReport{matchedSelectors: t.matchedSelectors}
+	var reportErr error
+
+	for _, field := range t.fields {
+		text := t.text[field.name]
+		diagnostic := supabase.FieldDiagnostic{
+			Field:    field.name,
+			Selector: t.matchedSelectors[field.name],
+			RawText:  text,
+			Status:   supabase.FieldResolved,
+		}
+
+		if field.kind == textValue {
+			report.snowpack = text
+			diagnostic.Value = text
+			if text == "" {
+				diagnostic.Status = supabase.FieldEmpty
+			}
+			report.diagnostics.Fields = append(report.diagnostics.Fields, diagnostic)
+			continue
+		}
+
+		value, err := processMeasurementText(text, field.propertyName, unit)
+		if err == nil && isPlaceholder(text) {
+			// The 0 is a fallback, not a reading
+			diagnostic.Status = supabase.FieldDefaulted
+		}
+		if err != nil {
+			value = 0
+			diagnostic.Status = supabase.FieldDefaulted
+			if text == "" {
+				diagnostic.Status = supabase.FieldEmpty
+			}
+			if field.kind == requiredMeasurement && reportErr == nil {
+				reportErr = err
+			} else if field.kind == optionalMeasurement && text != "" {
+				log.Printf("Warning: %v, using 0", err)
+			}
+		}
+		diagnostic.Value = value
+		*report.measurement(field.name) = value
+		report.diagnostics.Fields = append(report.diagnostics.Fields, diagnostic)
+	}
+
+	if reportErr != nil {
+		return report, reportErr
+	}
+	log.Printf("Base Depth: %.1f, Snow 24: %.1f, Snow 48: %.1f", report.baseDepth, report.snow24, report.snow48)
+	return report, nil
+}
+
+// Applies the field's pattern to the text read from the page, returning the
+// first capture group. Text that doesn't match the pattern is rejected so the
+// next selector can be tried
+func applyFieldPattern(field supabase.FieldSelector, text string) (string, bool) {
+	if field.Pattern == "" {
+		return text, true
+	}
+	pattern, err := regexp.Compile(field.Pattern)
+	if err != nil {
+		log.Printf("Warning: invalid pattern %q: %v", field.Pattern, err)
+		return "", false
+	}
+	match := pattern.FindStringSubmatch(text)
+	if match == nil {
+		return "", false
+	}
+	if len(match) > 1 {
+		return match[1], true
+	}
+	return match[0], true
+}
+
+// Reads the first of the field's selectors that matches inside node. The
+// lookups don't wait for the selectors to appear, so callers should wait for
+// the page to render first. Returns an empty selector when nothing matched
+func getFieldText(ctx context.Context, field supabase.FieldSelector, node *cdp.Node) (text, selector string) {
+	for _, selector := range field.Selectors {
+		var raw string
+		err := chromedp.Run(ctx,
+			chromedp.Text(selector, &raw, chromedp.ByQuery, chromedp.FromNode(node), chromedp.AtLeast(0)),
+		)
+		if err != nil {
+			continue
+		}
+		if text, ok := applyFieldPattern(field, raw); ok {
+			return text, selector
+		}
+	}
+	return "", ""
+}
+
+// goquery counterpart of getFieldText
+func selectionFieldText(selection *goquery.Selection, field supabase.FieldSelector) (text, selector string) {
+	for _, selector := range field.Selectors {
+		match := selection.Find(selector).First()
+		if match.Length() == 0 {
+			continue
+		}
+		raw := strings.Join(strings.Fields(match.Text()), " ")
+		if text, ok := applyFieldPattern(field, raw); ok {
+			return text, selector
+		}
+	}
+	return "", ""
+}
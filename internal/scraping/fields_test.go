@@ -0,0 +1,143 @@
+package scraping
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"powderhoundgo/internal/fixtures"
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyFieldPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		text    string
+		want    string
+		ok      bool
+	}{
+		{"no pattern", "", `52"`, `52"`, true},
+		{"capture group", `Base Depth:\s*(\d+)`, "Base Depth: 52 in", "52", true},
+		{"first capture group only", `(\d+)" / 48 Hours (\d+)"`, `Overnight 3" / 48 Hours 7"`, "3", true},
+		{"no capture group", `\d+`, "Base 52 in", "52", true},
+		{"no match", `Base Depth:\s*(\d+)`, "Surface: Packed Powder", "", false},
+		{"invalid pattern", `Base (\d+`, "Base 52", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := supabase.FieldSelector{Selectors: []string{".base"}, Pattern: tt.pattern}
+			got, ok := applyFieldPattern(field, tt.text)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
+
+// The fixture's markup has moved on from the primary selectors, so every
+// value has to come from a fallback
+func fallbackSelectorsConfig(server *fixtures.ReplayServer) supabase.ScrapingConfig {
+	return server.RewriteConfig(supabase.ScrapingConfig{
+		ID:            96,
+		Name:          "Fallback Selectors Resort",
+		ConditionsURL: "https://www.example-resort.com/conditions",
+		Conditions: supabase.ConditionsConfig{
+			ConditionsSelector: "main",
+			BaseDepthSelector: supabase.FieldSelector{
+				Selectors: []string{".snow-report__base > h5", "[data-stat='base']"},
+				Pattern:   `Base Depth:\s*(\d+)`,
+			},
+			Snow24Selector: supabase.FieldSelector{
+				Selectors: []string{".snow-report__24h > h5", "[data-stat='new-snow']"},
+				Pattern:   `Overnight\s*(\d+)`,
+			},
+			Snow48Selector: supabase.FieldSelector{
+				Selectors: []string{".snow-report__48h > h5", "[data-stat='new-snow']"},
+				Pattern:   `48 Hours\s*(\d+)`,
+			},
+			// The surface stat is matched first but rejected by the pattern
+			Snow7DaySelector: supabase.FieldSelector{
+				Selectors: []string{"[data-stat='surface']", "[data-stat='week']"},
+				Pattern:   `7 Day Total\s*(\d+)`,
+			},
+			SnowpackSelector: supabase.FieldSelector{
+				Selectors: []string{".snow-report__surface", "[data-stat='surface']"},
+				Pattern:   `Surface:\s*(.+)`,
+			},
+		},
+		Terrain: supabase.TerrainConfig{
+			TerrainSelector:   ".terrain-panel",
+			RunsOpenSelector:  ".terrain-panel__runs",
+			LiftsOpenSelector: ".terrain-panel__lifts",
+		},
+	})
+}
+
+func assertFallbackSelectorsResult(t *testing.T, data map[string]interface{}) {
+	t.Helper()
+	assert.Equal(t, 52.0, data["base_depth"], "base depth")
+	assert.Equal(t, 3.0, data["snow_past_24h"], "snow past 24h")
+	assert.Equal(t, 7.0, data["snow_past_48h"], "snow past 48h")
+	assert.Equal(t, 18.0, data["snow_past_week"], "snow past week")
+	assert.Equal(t, "Packed Powder", data["snow_type"], "snow type")
+	assert.Equal(t, 11, data["lifts_open"], "lifts open")
+	assert.Equal(t, 96, data["runs_open"], "runs open")
+	assert.Equal(t, map[string]string{
+		"baseDepth": "[data-stat='base']",
+		"snow24":    "[data-stat='new-snow']",
+		"snow48":    "[data-stat='new-snow']",
+		"snow7Day":  "[data-stat='week']",
+		"snowpack":  "[data-stat='surface']",
+	}, data["matched_selectors"])
+}
+
+func TestFallbackSelectorsFixture(t *testing.T) {
+	browserCtx := newReplayBrowser(t)
+
+	server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, "fallback-selectors"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(browserCtx, 60*time.Second)
+	defer cancel()
+
+	data, _, err := scrapeResortConditions(ctx, fallbackSelectorsConfig(server))
+	if assert.NoError(t, err) {
+		assertFallbackSelectorsResult(t, data)
+	}
+}
+
+func TestFallbackSelectorsFixtureHTTP(t *testing.T) {
+	server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, "fallback-selectors"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	data, diagnostics, err := scrapeResortConditionsHTTP(fallbackSelectorsConfig(server))
+	if assert.NoError(t, err) {
+		assertFallbackSelectorsResult(t, data)
+		assert.Contains(t, diagnostics.Fields, supabase.FieldDiagnostic{
+			Field: "snow24", Selector: "[data-stat='new-snow']", RawText: "3", Value: 3.0, Status: supabase.FieldResolved,
+		})
+		for _, field := range diagnostics.Fields {
+			assert.Equal(t, supabase.FieldResolved, field.Status, field.Field)
+		}
+	}
+
+	t.Run("fails when every selector is stale", func(t *testing.T) {
+		config := fallbackSelectorsConfig(server)
+		config.Conditions.BaseDepthSelector = supabase.NewFieldSelector(".snow-report__base > h5", ".base-depth")
+		_, diagnostics, err := scrapeResortConditionsHTTP(config)
+		assert.Error(t, err)
+		assert.Contains(t, diagnostics.Fields, supabase.FieldDiagnostic{
+			Field: "baseDepth", Value: 0.0, Status: supabase.FieldEmpty,
+		}, "diagnostics are returned with the error")
+	})
+}
@@ -0,0 +1,97 @@
+package scraping
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GeoJSON types, enough of them to find which forecast zone a point falls in
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// A ring of [lon, lat] positions
+type geoJSONRing [][]float64
+
+// contains reports whether the point falls inside a Polygon or MultiPolygon.
+// Points inside a hole of the polygon are outside it
+func (g geoJSONGeometry) contains(lat, lon float64) (bool, error) {
+	switch g.Type {
+	case "Polygon":
+		var polygon []geoJSONRing
+		if err := json.Unmarshal(g.Coordinates, &polygon); err != nil {
+			return false, fmt.Errorf("failed to parse polygon: %w", err)
+		}
+		return polygonContains(polygon, lat, lon), nil
+	case "MultiPolygon":
+		var polygons [][]geoJSONRing
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return false, fmt.Errorf("failed to parse multipolygon: %w", err)
+		}
+		for _, polygon := range polygons {
+			if polygonContains(polygon, lat, lon) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unsupported geometry type %q", g.Type)
+}
+
+// The first ring is the outer boundary, the rest are holes
+func polygonContains(polygon []geoJSONRing, lat, lon float64) bool {
+	if len(polygon) == 0 || !ringContains(polygon[0], lat, lon) {
+		return false
+	}
+	for _, hole := range polygon[1:] {
+		if ringContains(hole, lat, lon) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ray casting: counts how many edges a ray heading east from the point crosses
+func ringContains(ring geoJSONRing, lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		if len(ring[i]) < 2 || len(ring[j]) < 2 {
+			continue
+		}
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// featureID returns the feature's id, falling back to an "id" property, as text
+func (f geoJSONFeature) featureID() string {
+	id := f.ID
+	if id == nil {
+		id = f.Properties["id"]
+	}
+	switch v := id.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	}
+	return ""
+}
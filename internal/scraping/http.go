@@ -0,0 +1,155 @@
+package scraping
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Some resort sites reject requests that don't look like they came from a browser
+const httpUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/114.0.0.0 Safari/537.36"
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+func fetchDocument(url string) (*goquery.Document, error) {
+	log.Printf("Fetching: %s", url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", httpUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: unexpected status %s", url, resp.Status)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", url, err)
+	}
+	return doc, nil
+}
+
+// Mirrors the whitespace handling of the browser's innerText so both engines
+// hand the same strings to the numeric parsers
+func selectionText(selection *goquery.Selection, selector string) string {
+	if selector == "" {
+		return ""
+	}
+	return strings.Join(strings.Fields(selection.Find(selector).First().Text()), " ")
+}
+
+func processConditionsDocument(config supabase.ScrapingConfig, doc *goquery.Document) (resortReport, error) {
+	texts := newConditionsText(pageFields(config))
+
+	conditionsNodes := doc.Find(config.Conditions.ConditionsSelector)
+	log.Printf("Conditions nodes received, processing %d nodes", conditionsNodes.Length())
+
+	conditionsNodes.Each(func(_ int, node *goquery.Selection) {
+		for _, field := range texts.fields {
+			if !field.selector.IsSet() {
+				continue
+			}
+			if text, selector := selectionFieldText(node, field.selector); selector != "" {
+				texts.set(field, text, selector)
+			}
+		}
+	})
+
+	return texts.report(config.Unit)
+}
+
+func processTerrainDocument(config supabase.ScrapingConfig, doc *goquery.Document) (terrainCounts, error) {
+	var counts terrainCounts
+	if !config.Terrain.CountLifts || !config.Terrain.CountRuns {
+		var runsOpenTexts, liftsOpenTexts []string
+		doc.Find(config.Terrain.TerrainSelector).Each(func(_ int, node *goquery.Selection) {
+			if text := selectionText(node, config.Terrain.RunsOpenSelector); text != "" {
+				runsOpenTexts = append(runsOpenTexts, text)
+			}
+			if text := selectionText(node, config.Terrain.LiftsOpenSelector); text != "" {
+				liftsOpenTexts = append(liftsOpenTexts, text)
+			}
+		})
+
+		var err error
+		counts, err = convertTerrainTexts(config, runsOpenTexts, liftsOpenTexts)
+		if err != nil {
+			return counts, err
+		}
+	}
+
+	if config.Terrain.CountLifts {
+		counts.liftsOpen = doc.Find(config.Terrain.LiftStatusSelector).Length()
+		counts.record(countDiagnostic("liftsOpen", config.Terrain.LiftStatusSelector, counts.liftsOpen))
+	}
+	if config.Terrain.CountRuns {
+		counts.runsOpen = doc.Find(config.Terrain.RunStatusSelector).Length()
+		counts.record(countDiagnostic("runsOpen", config.Terrain.RunStatusSelector, counts.runsOpen))
+	}
+	return counts, nil
+}
+
+// Applies the steps that come before stage to doc. Only navigation changes
+// what a plain request sees, so waits, scrolls and sleeps are no-ops
+func applyDocumentSteps(config supabase.ScrapingConfig, doc *goquery.Document, stage string) (*goquery.Document, error) {
+	var err error
+	for _, step := range resolveSteps(config) {
+		if stepStage(step) != stage {
+			continue
+		}
+		switch step.Action {
+		case supabase.StepNavigate:
+			doc, err = fetchDocument(step.URL)
+			if err != nil {
+				return nil, err
+			}
+		case supabase.StepClick, supabase.StepSelectTab:
+			return nil, fmt.Errorf("%s requires a %s interaction, which the %s engine does not support", config.Name, step.Action, supabase.EngineHTTP)
+		case supabase.StepClickAll:
+			// Panels that the browser engine has to click open, such as lift and
+			// run status rows, are already in the server-rendered markup
+		}
+	}
+	return doc, nil
+}
+
+func scrapeResortConditionsHTTP(config supabase.ScrapingConfig) (map[string]interface{}, supabase.ScrapeDiagnostics, error) {
+	conditionsDoc, err := fetchDocument(config.ConditionsURL)
+	if err != nil {
+		return nil, supabase.ScrapeDiagnostics{}, err
+	}
+	conditionsDoc, err = applyDocumentSteps(config, conditionsDoc, supabase.StageConditions)
+	if err != nil {
+		return nil, supabase.ScrapeDiagnostics{}, err
+	}
+	report, err := processConditionsDocument(config, conditionsDoc)
+	if err != nil {
+		return nil, report.diagnostics, captureDocumentFailure(config.Name, conditionsDoc, err)
+	}
+
+	terrainDoc, err := applyDocumentSteps(config, conditionsDoc, supabase.StageTerrain)
+	if err != nil {
+		return nil, report.diagnostics, err
+	}
+	counts, err := processTerrainDocument(config, terrainDoc)
+	report.setTerrain(counts)
+	if err != nil {
+		return nil, report.diagnostics, captureDocumentFailure(config.Name, terrainDoc, err)
+	}
+
+	return buildResortConditions(config, report), report.diagnostics, nil
+}
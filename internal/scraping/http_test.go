@@ -0,0 +1,59 @@
+package scraping
+
+import (
+	"path/filepath"
+	"testing"
+
+	"powderhoundgo/internal/fixtures"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrapeResortFixturesHTTP(t *testing.T) {
+	for _, tt := range resortFixtureTests {
+		t.Run(tt.mountain, func(t *testing.T) {
+			server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, tt.mountain))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer server.Close()
+
+			config := server.RewriteConfig(loadTestConfig(t, tt.mountain))
+			data, _, err := scrapeResortConditionsHTTP(config)
+			if config.ClickSelector != "" {
+				assert.Error(t, err, "click interactions are not supported")
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.baseDepth, data["base_depth"], "base depth")
+			assert.Equal(t, tt.snow24, data["snow_past_24h"], "snow past 24h")
+			assert.Equal(t, tt.snow48, data["snow_past_48h"], "snow past 48h")
+			assert.Equal(t, tt.liftsOpen, data["lifts_open"], "lifts open")
+			assert.Equal(t, tt.runsOpen, data["runs_open"], "runs open")
+		})
+	}
+}
+
+func TestTerrainOptionsFixtureHTTP(t *testing.T) {
+	server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, "terrain-options"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	for _, tt := range terrainOptionTests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _, err := scrapeResortConditionsHTTP(terrainOptionsConfig(server, tt.modify))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, 40.0, data["base_depth"], "base depth")
+			assert.Equal(t, tt.liftsOpen, data["lifts_open"], "lifts open")
+			assert.Equal(t, tt.runsOpen, data["runs_open"], "runs open")
+		})
+	}
+}
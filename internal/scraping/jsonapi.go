@@ -0,0 +1,155 @@
+package scraping
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"powderhoundgo/internal/supabase"
+)
+
+func fetchJSON(api supabase.APIConfig) (interface{}, error) {
+	log.Printf("Fetching: %s", api.URL)
+	req, err := http.NewRequest(http.MethodGet, api.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", httpUserAgent)
+	req.Header.Set("Accept", "application/json")
+	for key, value := range api.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", api.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: unexpected status %s", api.URL, resp.Status)
+	}
+
+	var data interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", api.URL, err)
+	}
+	return data, nil
+}
+
+// Looks up a value in the feed and formats it as text so it goes through the
+// same parsing as values scraped from a page. Unmapped fields return "" and
+// nulls "null", which is parsed as a placeholder
+func jsonPathText(data interface{}, path string, propertyName string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	value, err := evaluateJSONPath(data, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to find %s: %w", propertyName, err)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "null", nil
+	}
+	return "", fmt.Errorf("unexpected value for %s at %s: %v", propertyName, path, value)
+}
+
+func processAPIResponse(config supabase.ScrapingConfig, data interface{}) (resortReport, error) {
+	texts := newConditionsText(apiFields(config))
+	for _, field := range texts.fields {
+		path := field.selector.String()
+		text, err := jsonPathText(data, path, field.propertyName)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		if text != "" {
+			texts.set(field, text, path)
+		}
+	}
+
+	// Base depth and recent snowfall are required just like the page selectors
+	report, err := texts.report(config.Unit)
+	if err != nil {
+		return report, err
+	}
+
+	// Feeds without terrain data leave lifts and runs at 0
+	api := config.API
+	counts := []struct {
+		name         string
+		path         string
+		propertyName string
+		target       *int
+	}{
+		{"liftsOpen", api.LiftsOpen, "lifts open", &report.liftsOpen},
+		{"runsOpen", api.RunsOpen, "runs open", &report.runsOpen},
+	}
+	for _, field := range counts {
+		if field.path == "" {
+			continue
+		}
+		diagnostic := supabase.FieldDiagnostic{Field: field.name, Status: supabase.FieldEmpty, Value: 0}
+		text, err := jsonPathText(data, field.path, field.propertyName)
+		if err != nil {
+			report.diagnostics.Fields = append(report.diagnostics.Fields, diagnostic)
+			return report, err
+		}
+		diagnostic.Selector = field.path
+		diagnostic.RawText = text
+
+		// A closed lift or an unreported count comes back as null, "N/A" or
+		// "", which stays 0 just like a placeholder on the terrain pages
+		if !countDefaults(text) {
+			// Counts can come back as "12/24" or "12 of 24" just like on the terrain pages
+			text, err = removeDenominator(text)
+			if err == nil {
+				*field.target, err = processTextAndConvertToInt(text, field.propertyName)
+			}
+			if err != nil {
+				diagnostic.Status = supabase.FieldDefaulted
+				report.diagnostics.Fields = append(report.diagnostics.Fields, diagnostic)
+				return report, err
+			}
+		}
+		diagnostic.Value = *field.target
+		switch {
+		case diagnostic.RawText == "":
+			diagnostic.Status = supabase.FieldEmpty
+		case countDefaults(diagnostic.RawText):
+			diagnostic.Status = supabase.FieldDefaulted
+		default:
+			diagnostic.Status = supabase.FieldResolved
+		}
+		report.diagnostics.Fields = append(report.diagnostics.Fields, diagnostic)
+	}
+
+	return report, nil
+}
+
+func scrapeResortConditionsJSON(config supabase.ScrapingConfig) (map[string]interface{}, supabase.ScrapeDiagnostics, error) {
+	if config.API.URL == "" {
+		return nil, supabase.ScrapeDiagnostics{}, fmt.Errorf("%s uses the %s engine but has no api.url", config.Name, supabase.EngineJSON)
+	}
+
+	data, err := fetchJSON(config.API)
+	if err != nil {
+		return nil, supabase.ScrapeDiagnostics{}, err
+	}
+
+	report, err := processAPIResponse(config, data)
+	if err != nil {
+		return nil, report.diagnostics, err
+	}
+
+	return buildResortConditions(config, report), report.diagnostics, nil
+}
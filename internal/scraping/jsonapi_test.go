@@ -0,0 +1,100 @@
+package scraping
+
+import (
+	"path/filepath"
+	"testing"
+
+	"powderhoundgo/internal/fixtures"
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrapeResortConditionsJSON(t *testing.T) {
+	server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, "json-api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	config := supabase.ScrapingConfig{
+		ID:     99,
+		Name:   "JSON API Resort",
+		Engine: supabase.EngineJSON,
+		API: supabase.APIConfig{
+			URL:         server.ReplayURL("https://api.example-resort.com/v1/snow-report"),
+			BaseDepth:   "$.snowReport.baseDepth.value",
+			Snowpack:    "$.snowReport.surface",
+			SeasonTotal: "$.snowReport.seasonTotal",
+			Snow24:      "$.snowReport.measurements[?(@.period=='24h')].value",
+			Snow48:      "$.snowReport.measurements[?(@.period=='48h')].value",
+			Snow7Day:    "$.snowReport.measurements[?(@.period=='7d')].value",
+			LiftsOpen:   "$.terrain.lifts.open",
+			RunsOpen:    "$.terrain.trails.open",
+		},
+	}
+
+	data, _, err := scrapeResortConditionsJSON(config)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 99, data["mountain_id"])
+	// 47.5" keeps its decimal rather than being truncated
+	assert.Equal(t, 47.5, data["base_depth"])
+	assert.Equal(t, 3.0, data["snow_past_24h"])
+	assert.Equal(t, 6.0, data["snow_past_48h"])
+	assert.Equal(t, 14.0, data["snow_past_week"])
+	assert.Equal(t, 188.0, data["snow_total"])
+	assert.Equal(t, "Packed Powder", data["snow_type"])
+	assert.Equal(t, 19, data["lifts_open"])
+	assert.Equal(t, 98, data["runs_open"])
+
+	t.Run("fails when a required field is missing", func(t *testing.T) {
+		missing := config
+		missing.API.Snow48 = "$.snowReport.measurements[?(@.period=='72h')].value"
+		_, _, err := scrapeResortConditionsJSON(missing)
+		assert.Error(t, err)
+	})
+}
+
+func TestProcessAPIResponseUnreportedValues(t *testing.T) {
+	config := supabase.ScrapingConfig{
+		Engine: supabase.EngineJSON,
+		API: supabase.APIConfig{
+			BaseDepth: "$.baseDepth",
+			Snow24:    "$.snow24",
+			Snow48:    "$.snow48",
+			LiftsOpen: "$.liftsOpen",
+			RunsOpen:  "$.runsOpen",
+		},
+	}
+	data := map[string]interface{}{
+		"baseDepth": 38.0,
+		"snow24":    nil,
+		"snow48":    "N/A",
+		"liftsOpen": nil,
+		"runsOpen":  "N/A",
+	}
+
+	report, err := processAPIResponse(config, data)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 0.0, report.snow24)
+	assert.Equal(t, 0.0, report.snow48)
+	assert.Equal(t, 0, report.liftsOpen)
+	assert.Equal(t, 0, report.runsOpen)
+
+	statuses := map[string]string{}
+	for _, field := range report.diagnostics.Fields {
+		statuses[field.Field] = field.Status
+	}
+	assert.Equal(t, map[string]string{
+		"baseDepth": supabase.FieldResolved,
+		"snow24":    supabase.FieldDefaulted,
+		"snow48":    supabase.FieldDefaulted,
+		"liftsOpen": supabase.FieldDefaulted,
+		"runsOpen":  supabase.FieldDefaulted,
+	}, statuses)
+}
@@ -0,0 +1,201 @@
+package scraping
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Evaluates a JSONPath expression against decoded JSON and returns the first match.
+//
+// Supports the subset of JSONPath needed to map snow report feeds:
+// child access ($.a.b, $['a']), array indexes ($.a[0], $.a[-1]),
+// wildcards ($.a[*], $.a.*) and equality filters ($.a[?(@.name=='Base')])
+func evaluateJSONPath(data interface{}, path string) (interface{}, error) {
+	matches, err := queryJSONPath(data, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no value found at %s", path)
+	}
+	return matches[0], nil
+}
+
+func queryJSONPath(data interface{}, path string) ([]interface{}, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") && !strings.HasPrefix(path, "@") {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", path)
+	}
+
+	nodes := []interface{}{data}
+	rest := path[1:]
+	for rest != "" {
+		var step func(interface{}) []interface{}
+		var err error
+		step, rest, err = nextJSONPathStep(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %q: %w", path, err)
+		}
+
+		var next []interface{}
+		for _, node := range nodes {
+			next = append(next, step(node)...)
+		}
+		nodes = next
+	}
+	return nodes, nil
+}
+
+// Parses the next step of a path, returning a function that maps a node to its
+// matching children along with the unparsed remainder of the path
+func nextJSONPathStep(path string) (func(interface{}) []interface{}, string, error) {
+	switch {
+	case strings.HasPrefix(path, ".."):
+		return nil, "", fmt.Errorf("recursive descent is not supported")
+
+	case strings.HasPrefix(path, "."):
+		end := strings.IndexAny(path[1:], ".[")
+		if end == -1 {
+			end = len(path) - 1
+		}
+		name := path[1 : end+1]
+		if name == "" {
+			return nil, "", fmt.Errorf("empty property name")
+		}
+		if name == "*" {
+			return jsonWildcard, path[end+1:], nil
+		}
+		return jsonChild(name), path[end+1:], nil
+
+	case strings.HasPrefix(path, "[?("):
+		end := strings.Index(path, ")]")
+		if end == -1 {
+			return nil, "", fmt.Errorf("unterminated filter")
+		}
+		filter, err := parseJSONPathFilter(path[3:end])
+		if err != nil {
+			return nil, "", err
+		}
+		return filter, path[end+2:], nil
+
+	case strings.HasPrefix(path, "['"), strings.HasPrefix(path, "[\""):
+		quote := path[1]
+		end := strings.IndexByte(path[2:], quote)
+		if end == -1 || len(path) < end+4 || path[end+3] != ']' {
+			return nil, "", fmt.Errorf("unterminated property name")
+		}
+		return jsonChild(path[2 : end+2]), path[end+4:], nil
+
+	case strings.HasPrefix(path, "["):
+		end := strings.IndexByte(path, ']')
+		if end == -1 {
+			return nil, "", fmt.Errorf("unterminated index")
+		}
+		index := strings.TrimSpace(path[1:end])
+		if index == "*" {
+			return jsonWildcard, path[end+1:], nil
+		}
+		i, err := strconv.Atoi(index)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid array index %q", index)
+		}
+		return jsonIndex(i), path[end+1:], nil
+	}
+
+	return nil, "", fmt.Errorf("unexpected %q", path)
+}
+
+func jsonChild(name string) func(interface{}) []interface{} {
+	return func(node interface{}) []interface{} {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value, ok := object[name]
+		if !ok {
+			return nil
+		}
+		return []interface{}{value}
+	}
+}
+
+func jsonIndex(i int) func(interface{}) []interface{} {
+	return func(node interface{}) []interface{} {
+		array, ok := node.([]interface{})
+		if !ok {
+			return nil
+		}
+		// The step is reused for every array it's applied to, so a negative
+		// index is resolved per array
+		idx := i
+		if idx < 0 {
+			idx += len(array)
+		}
+		if idx < 0 || idx >= len(array) {
+			return nil
+		}
+		return []interface{}{array[idx]}
+	}
+}
+
+func jsonWildcard(node interface{}) []interface{} {
+	switch value := node.(type) {
+	case []interface{}:
+		return value
+	case map[string]interface{}:
+		// Sorted so that "first match" is stable across runs
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		children := make([]interface{}, 0, len(value))
+		for _, key := range keys {
+			children = append(children, value[key])
+		}
+		return children
+	}
+	return nil
+}
+
+// Parses filters of the form @.field == 'value' and keeps the array elements that match
+func parseJSONPathFilter(expression string) (func(interface{}) []interface{}, error) {
+	left, right, found := strings.Cut(expression, "==")
+	if !found {
+		return nil, fmt.Errorf("only == filters are supported")
+	}
+	left, right = strings.TrimSpace(left), strings.TrimSpace(right)
+	if !strings.HasPrefix(left, "@") {
+		return nil, fmt.Errorf("filter must compare a field of @")
+	}
+
+	var want interface{}
+	switch {
+	case len(right) >= 2 && (right[0] == '\'' || right[0] == '"') && right[len(right)-1] == right[0]:
+		want = right[1 : len(right)-1]
+	case right == "true" || right == "false":
+		want = right == "true"
+	default:
+		number, err := strconv.ParseFloat(right, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter value %q", right)
+		}
+		want = number
+	}
+
+	return func(node interface{}) []interface{} {
+		var matches []interface{}
+		for _, element := range jsonWildcard(node) {
+			values, err := queryJSONPath(element, left)
+			if err != nil || len(values) == 0 {
+				continue
+			}
+			if values[0] == want {
+				matches = append(matches, element)
+			}
+		}
+		return matches
+	}, nil
+}
@@ -0,0 +1,76 @@
+package scraping
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateJSONPath(t *testing.T) {
+	var data interface{}
+	err := json.Unmarshal([]byte(`{
+		"report": {
+			"base": 42,
+			"snow.24h": "3\"",
+			"items": [
+				{"name": "Base", "value": 40},
+				{"name": "Summit", "value": 55, "open": true}
+			]
+		}
+	}`), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"$.report.base", 42.0},
+		{"$['report']['snow.24h']", "3\""},
+		{"$.report.items[1].value", 55.0},
+		{"$.report.items[-1].name", "Summit"},
+		{"$.report.items[*].value", 40.0},
+		{"$.report.items[?(@.name=='Summit')].value", 55.0},
+		{"$.report.items[?(@.open==true)].name", "Summit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := evaluateJSONPath(data, tt.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("returns an error when nothing matches", func(t *testing.T) {
+		_, err := evaluateJSONPath(data, "$.report.items[5].value")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unsupported syntax", func(t *testing.T) {
+		_, err := evaluateJSONPath(data, "$..value")
+		assert.Error(t, err)
+		_, err = evaluateJSONPath(data, "report.base")
+		assert.Error(t, err)
+	})
+}
+
+func TestQueryJSONPathNegativeIndex(t *testing.T) {
+	var data interface{}
+	err := json.Unmarshal([]byte(`{
+		"areas": [
+			{"lifts": ["Pony Express", "Lumberjack", "Summit"]},
+			{"lifts": ["Tubing Carpet"]},
+			{"lifts": ["Ridge", "Storm Peak"]}
+		]
+	}`), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same step is applied to every area's array
+	matches, err := queryJSONPath(data, "$.areas[*].lifts[-1]")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"Summit", "Tubing Carpet", "Storm Peak"}, matches)
+}
@@ -0,0 +1,111 @@
+package scraping
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"powderhoundgo/internal/supabase"
+)
+
+var inchesPerUnit = map[string]float64{
+	supabase.UnitInches:      1,
+	supabase.UnitFeet:        12,
+	supabase.UnitCentimeters: 1 / 2.54,
+}
+
+// Spellings of each unit as they appear after a number on resort pages
+var unitAliases = map[string]string{
+	`"`:           supabase.UnitInches,
+	"”":           supabase.UnitInches,
+	"″":           supabase.UnitInches,
+	"in":          supabase.UnitInches,
+	"inch":        supabase.UnitInches,
+	"inches":      supabase.UnitInches,
+	"'":           supabase.UnitFeet,
+	"’":           supabase.UnitFeet,
+	"′":           supabase.UnitFeet,
+	"ft":          supabase.UnitFeet,
+	"foot":        supabase.UnitFeet,
+	"feet":        supabase.UnitFeet,
+	"cm":          supabase.UnitCentimeters,
+	"centimeter":  supabase.UnitCentimeters,
+	"centimeters": supabase.UnitCentimeters,
+	"centimetre":  supabase.UnitCentimeters,
+	"centimetres": supabase.UnitCentimeters,
+}
+
+var vulgarFractions = map[string]float64{
+	"¼": 1.0 / 4,
+	"½": 1.0 / 2,
+	"¾": 3.0 / 4,
+	"⅓": 1.0 / 3,
+	"⅔": 2.0 / 3,
+	"⅛": 1.0 / 8,
+	"⅜": 3.0 / 8,
+	"⅝": 5.0 / 8,
+	"⅞": 7.0 / 8,
+}
+
+// A number ("12", "1.5", "1,5", "1,250"), an optional vulgar fraction and an
+// optional unit. Every part is optional so matches without a number or
+// fraction are skipped
+var measurementPattern = regexp.MustCompile(`(?i)(\d{1,3}(?:,\d{3})+(?:\.\d+)?|\d*[.,]?\d+)?\s*([¼½¾⅓⅔⅛⅜⅝⅞])?\s*(centimet(?:er|re)s?|cm|inch(?:es)?|in|feet|foot|ft|["”″'’′])?`)
+
+// Parses the first measurement in text, e.g. `1.5"`, "1½ in", "45 cm" or
+// "3 ft", and returns it in inches. A unit written in the text takes
+// precedence over sourceUnit, the unit the resort reports in when it doesn't
+// say. An empty sourceUnit means inches
+func parseMeasurement(text, sourceUnit string) (float64, error) {
+	trimmedText := strings.TrimSpace(text)
+	if trimmedText == "" || isPlaceholder(trimmedText) {
+		return 0, nil
+	}
+
+	for _, match := range measurementPattern.FindAllStringSubmatch(trimmedText, -1) {
+		number, fraction, unit := match[1], match[2], match[3]
+		if number == "" && fraction == "" {
+			continue
+		}
+
+		value := 0.0
+		if number != "" {
+			// A comma followed by exactly three digits is a thousands separator,
+			// any other lone comma is a decimal comma
+			if strings.Count(number, ",") == 1 && !strings.Contains(number, ".") && len(number)-strings.Index(number, ",") != 4 {
+				number = strings.Replace(number, ",", ".", 1)
+			}
+			parsed, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", ""), 64)
+			if err != nil {
+				return 0, fmt.Errorf("failed to parse measurement %q: %w", text, err)
+			}
+			value = parsed
+		}
+		value += vulgarFractions[fraction]
+
+		if unit == "" {
+			unit = sourceUnit
+		}
+		if unit == "" {
+			unit = supabase.UnitInches
+		}
+		if alias, ok := unitAliases[strings.ToLower(unit)]; ok {
+			unit = alias
+		}
+		factor, ok := inchesPerUnit[unit]
+		if !ok {
+			return 0, fmt.Errorf("unknown unit %q", unit)
+		}
+		return value * factor, nil
+	}
+
+	return 0, fmt.Errorf("no measurement found in %q", text)
+}
+
+// Rounds inches to a tenth, converted centimeters and feet otherwise carry
+// float noise such as 17.716535433070867 into the database
+func roundInches(value float64) float64 {
+	return math.Round(value*10) / 10
+}
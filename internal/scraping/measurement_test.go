@@ -0,0 +1,104 @@
+package scraping
+
+import (
+	"testing"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMeasurement(t *testing.T) {
+	tests := []struct {
+		text       string
+		sourceUnit string
+		want       float64
+	}{
+		{`38"`, "", 38},
+		{`1.5"`, "", 1.5},
+		{"1½ in", "", 1.5},
+		{"½\"", "", 0.5},
+		{"1 ¾ inches", "", 1.75},
+		{".5 in", "", 0.5},
+		{"12", supabase.UnitInches, 12},
+		{"4 ft", "", 48},
+		{"3'", "", 36},
+		{"45 cm", "", 45 / 2.54},
+		{"45", supabase.UnitCentimeters, 45 / 2.54},
+		{"12,5 cm", "", 12.5 / 2.54},
+		{"1,250 cm", "", 1250 / 2.54},
+		{"1,250.5 cm", "", 1250.5 / 2.54},
+		{"Base Depth: 52 in", "", 52},
+		{`20" at the summit`, supabase.UnitCentimeters, 20},
+		{"--", "", 0},
+		{"—", "", 0},
+		{"", supabase.UnitCentimeters, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := parseMeasurement(tt.text, tt.sourceUnit)
+			if assert.NoError(t, err) {
+				assert.InDelta(t, tt.want, got, 0.0001)
+			}
+		})
+	}
+
+	t.Run("no number", func(t *testing.T) {
+		_, err := parseMeasurement("Packed Powder", "")
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown source unit", func(t *testing.T) {
+		_, err := parseMeasurement("12", "yd")
+		assert.Error(t, err)
+	})
+}
+
+func TestConditionsReportUnits(t *testing.T) {
+	config := supabase.ScrapingConfig{
+		ID:   1,
+		Name: "Metric Resort",
+		Unit: supabase.UnitCentimeters,
+		Conditions: supabase.ConditionsConfig{
+			BaseDepthSelector:   supabase.NewFieldSelector(".base"),
+			Snow24Selector:      supabase.NewFieldSelector(".24h"),
+			Snow48Selector:      supabase.NewFieldSelector(".48h"),
+			SeasonTotalSelector: supabase.NewFieldSelector(".season"),
+			Snow7DaySelector:    supabase.NewFieldSelector(".week"),
+		},
+	}
+	texts := newConditionsText(pageFields(config))
+	for _, field := range texts.fields {
+		text := map[string]string{
+			"baseDepth":   "120",
+			"snow24":      "1.5",
+			"snow48":      "4",
+			"seasonTotal": "310",
+			"snow7Day":    `9"`,
+		}[field.name]
+		texts.set(field, text, field.selector.Selectors[0])
+	}
+
+	report, err := texts.report(config.Unit)
+	if !assert.NoError(t, err) {
+		return
+	}
+	data := buildResortConditions(config, report)
+	// Converted values are rounded to a tenth of an inch, not to whole inches
+	assert.Equal(t, 47.2, data["base_depth"])
+	assert.Equal(t, 0.6, data["snow_past_24h"])
+	assert.Equal(t, 1.6, data["snow_past_48h"])
+	assert.Equal(t, 122.0, data["snow_total"], "season total is in the resort's unit")
+	assert.Equal(t, 9.0, data["snow_past_week"], "an explicit unit wins over the resort's unit")
+
+	t.Run("inches keep their decimals", func(t *testing.T) {
+		inches := config
+		inches.Unit = ""
+		report, err := texts.report(inches.Unit)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 1.5, buildResortConditions(inches, report)["snow_past_24h"])
+	})
+}
@@ -0,0 +1,108 @@
+package scraping
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Elevation bands, named like the AvalancheDangerLevel fields
+const (
+	ElevationAboveTreeline = "above_treeline"
+	ElevationNearTreeline  = "near_treeline"
+	ElevationBelowTreeline = "below_treeline"
+)
+
+// Aspects clockwise from north, the order they're listed in
+var aspects = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+var elevations = []string{ElevationAboveTreeline, ElevationNearTreeline, ElevationBelowTreeline}
+
+// AvalancheProblem is one of the avalanche problems in a forecast
+type AvalancheProblem struct {
+	// e.g. "Wind Slab" or "Persistent Slab"
+	Type string `json:"type"`
+	// Aspects and elevations the problem is found on, from the rose
+	Aspects    []string `json:"aspects"`
+	Elevations []string `json:"elevations"`
+	// The aspects the problem is found on in each elevation band, keyed by
+	// the Elevation constants
+	Rose map[string][]string `json:"rose"`
+	// e.g. "Likely" or "Very Likely"
+	Likelihood string `json:"likelihood"`
+	// Expected size on the destructive size scale, e.g. 1 to 2.5
+	MinSize      float64 `json:"min_size"`
+	MaxSize      float64 `json:"max_size"`
+	TravelAdvice string  `json:"travel_advice"`
+}
+
+// An aspect and elevation band a problem is found on, e.g. NE near treeline
+type aspectElevation struct {
+	aspect    string
+	elevation string
+}
+
+// Fills in the rose and the aspect and elevation lists in their usual order,
+// whatever order the feed listed them in. Unknown aspects and elevations are
+// dropped
+func (p *AvalancheProblem) setLocations(locations []aspectElevation) {
+	found := map[aspectElevation]bool{}
+	for _, location := range locations {
+		found[location] = true
+	}
+
+	p.Rose = map[string][]string{}
+	p.Aspects, p.Elevations = nil, nil
+	aspectFound := map[string]bool{}
+	for _, elevation := range elevations {
+		for _, aspect := range aspects {
+			if !found[aspectElevation{aspect, elevation}] {
+				continue
+			}
+			p.Rose[elevation] = append(p.Rose[elevation], aspect)
+			aspectFound[aspect] = true
+		}
+		if len(p.Rose[elevation]) > 0 {
+			p.Elevations = append(p.Elevations, elevation)
+		}
+	}
+	for _, aspect := range aspects {
+		if aspectFound[aspect] {
+			p.Aspects = append(p.Aspects, aspect)
+		}
+	}
+}
+
+// Turns "windSlab", "wind_slab" or "wind slab" into "Wind Slab"
+func titleCase(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			word[0] = unicode.ToUpper(word[0])
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case r == '_' || r == '-' || unicode.IsSpace(r):
+			flush()
+		case unicode.IsUpper(r):
+			flush()
+			word = append(word, unicode.ToLower(r))
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+	return strings.Join(words, " ")
+}
+
+func parseProblemSize(size string) float64 {
+	value, err := strconv.ParseFloat(strings.TrimSpace(size), 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
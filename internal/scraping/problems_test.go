@@ -0,0 +1,33 @@
+package scraping
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTitleCase(t *testing.T) {
+	assert.Equal(t, "Wind Slab", titleCase("windSlab"))
+	assert.Equal(t, "Deep Persistent Slab", titleCase("deepPersistentSlab"))
+	assert.Equal(t, "Very Likely", titleCase("very_likely"))
+	assert.Equal(t, "Loose Wet", titleCase("loose wet"))
+	assert.Equal(t, "Cornice", titleCase("cornice"))
+	assert.Equal(t, "", titleCase(""))
+}
+
+func TestSetLocations(t *testing.T) {
+	var problem AvalancheProblem
+	problem.setLocations([]aspectElevation{
+		{"NW", ElevationBelowTreeline},
+		{"N", ElevationAboveTreeline},
+		{"N", ElevationAboveTreeline},
+		{"", ElevationNearTreeline},
+	})
+
+	assert.Equal(t, []string{"N", "NW"}, problem.Aspects)
+	assert.Equal(t, []string{ElevationAboveTreeline, ElevationBelowTreeline}, problem.Elevations)
+	assert.Equal(t, map[string][]string{
+		ElevationAboveTreeline: {"N"},
+		ElevationBelowTreeline: {"NW"},
+	}, problem.Rose)
+}
@@ -0,0 +1,133 @@
+package scraping
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AvalancheProvider fetches forecasts from one avalanche center's feed
+type AvalancheProvider interface {
+	// Name identifies the provider in logs, e.g. "caic"
+	Name() string
+	// Covers reports whether the provider publishes forecasts for the
+	// mountain, going by its avalanche center when set and its coordinates
+	// otherwise
+	Covers(mountain MountainCoordinates) bool
+	Forecast(mountain MountainCoordinates, now time.Time) (*AvalancheForecast, error)
+}
+
+// AvalancheRegistry picks the providers for a mountain, in the order they
+// were registered
+type AvalancheRegistry struct {
+	providers []AvalancheProvider
+}
+
+func NewAvalancheRegistry(providers ...AvalancheProvider) *AvalancheRegistry {
+	return &AvalancheRegistry{providers: providers}
+}
+
+// DefaultAvalancheRegistry tries the regional centers' own feeds first and
+// falls back to the national avalanche.org map layer, which has every
+// center's zones but only the overall danger
+func DefaultAvalancheRegistry() *AvalancheRegistry {
+	return NewAvalancheRegistry(
+		NewCAICClient(),
+		caicPageProvider{},
+		NewNWACClient(),
+		NewAvalancheOrgClient(),
+	)
+}
+
+// ProvidersFor returns the providers that cover the mountain
+func (r *AvalancheRegistry) ProvidersFor(mountain MountainCoordinates) []AvalancheProvider {
+	var providers []AvalancheProvider
+	for _, provider := range r.providers {
+		if provider.Covers(mountain) {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
+// Forecast returns the forecast from the first provider covering the
+// mountain that has one
+func (r *AvalancheRegistry) Forecast(mountain MountainCoordinates, now time.Time) (*AvalancheForecast, error) {
+	var errs []error
+	for _, provider := range r.ProvidersFor(mountain) {
+		forecast, err := provider.Forecast(mountain, now)
+		if err == nil {
+			forecast.sanitize()
+			log.Printf("Fetched avalanche forecast for mountain %d from %s", mountain.MountainID, provider.Name())
+			return forecast, nil
+		}
+		log.Printf("Warning: %s has no avalanche forecast for mountain %d: %v", provider.Name(), mountain.MountainID, err)
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no avalanche provider covers mountain %d at %f,%f", mountain.MountainID, mountain.Lat, mountain.Lon)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// A latitude/longitude box roughly around a center's forecast zones
+type geoBounds struct {
+	minLat, maxLat float64
+	minLon, maxLon float64
+}
+
+func (b geoBounds) contains(lat, lon float64) bool {
+	return lat >= b.minLat && lat <= b.maxLat && lon >= b.minLon && lon <= b.maxLon
+}
+
+// A mountain with an avalanche center set only goes to that center's
+// providers, the rest are matched by where they are
+func coversRegion(mountain MountainCoordinates, center string, bounds geoBounds) bool {
+	if mountain.AvalancheCenter != "" {
+		return strings.EqualFold(mountain.AvalancheCenter, center)
+	}
+	return bounds.contains(mountain.Lat, mountain.Lon)
+}
+
+// The rendered CAIC page, scraped in Chrome when the CAIC API fails
+type caicPageProvider struct{}
+
+func (caicPageProvider) Name() string {
+	return "caic-page"
+}
+
+func (caicPageProvider) Covers(mountain MountainCoordinates) bool {
+	return coversRegion(mountain, caicCenterID, coloradoBounds)
+}
+
+func (caicPageProvider) Forecast(mountain MountainCoordinates, now time.Time) (*AvalancheForecast, error) {
+	return scrapeAvalanchePage(mountain)
+}
+
+func getJSON(client *http.Client, url string, v interface{}) error {
+	log.Printf("Fetching: %s", url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", httpUserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: unexpected status %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package scraping
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProvider struct {
+	name    string
+	center  string
+	bounds  geoBounds
+	level   int
+	err     error
+	fetched int
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Covers(mountain MountainCoordinates) bool {
+	return coversRegion(mountain, p.center, p.bounds)
+}
+
+func (p *fakeProvider) Forecast(mountain MountainCoordinates, now time.Time) (*AvalancheForecast, error) {
+	p.fetched++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &AvalancheForecast{
+		MountainID:         mountain.MountainID,
+		AvalancheSummary:   `<p onclick="steal()">Avoid <b>wind-loaded</b> slopes.</p><script>alert(1)</script>`,
+		OverallDangerLevel: p.level,
+		Problems:           []AvalancheProblem{{Type: "Wind Slab", TravelAdvice: `<p style="color: red">Stay off ridgelines.</p>`}},
+	}, nil
+}
+
+func providerNames(providers []AvalancheProvider) []string {
+	var names []string
+	for _, provider := range providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+func TestDefaultRegistryProvidersFor(t *testing.T) {
+	registry := DefaultAvalancheRegistry()
+
+	tests := []struct {
+		name     string
+		mountain MountainCoordinates
+		expected []string
+	}{
+		{"Colorado", MountainCoordinates{Lat: 39.6403, Lon: -105.8719}, []string{"caic", "caic-page", "avalanche.org"}},
+		{"Washington", MountainCoordinates{Lat: 47.4446, Lon: -121.4271}, []string{"nwac", "avalanche.org"}},
+		{"Utah", MountainCoordinates{Lat: 40.5829, Lon: -111.6556}, []string{"avalanche.org"}},
+		{"center overrides coordinates", MountainCoordinates{Lat: 39.6403, Lon: -105.8719, AvalancheCenter: "nwac"}, []string{"nwac", "avalanche.org"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, providerNames(registry.ProvidersFor(test.mountain)))
+		})
+	}
+}
+
+func TestRegistryForecast(t *testing.T) {
+	colorado := geoBounds{minLat: 37, maxLat: 41, minLon: -109, maxLon: -102}
+	mountain := MountainCoordinates{MountainID: 7, Lat: 39.6, Lon: -105.9}
+
+	t.Run("first provider with a forecast wins", func(t *testing.T) {
+		failing := &fakeProvider{name: "api", center: "CAIC", bounds: colorado, err: errors.New("bad gateway")}
+		page := &fakeProvider{name: "page", center: "CAIC", bounds: colorado, level: 3}
+		national := &fakeProvider{name: "national", center: "CAIC", bounds: colorado, level: 2}
+		registry := NewAvalancheRegistry(failing, page, national)
+
+		forecast, err := registry.Forecast(mountain, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 3, forecast.OverallDangerLevel)
+		assert.Equal(t, 0, national.fetched)
+
+		assert.Equal(t, "<p>Avoid <b>wind-loaded</b> slopes.</p>", forecast.AvalancheSummary)
+		assert.Equal(t, "Avoid wind-loaded slopes.", forecast.SummaryText)
+		assert.Equal(t, "Avoid **wind-loaded** slopes.", forecast.SummaryMarkdown)
+		assert.Equal(t, "<p>Stay off ridgelines.</p>", forecast.Problems[0].TravelAdvice)
+	})
+
+	t.Run("every provider fails", func(t *testing.T) {
+		registry := NewAvalancheRegistry(
+			&fakeProvider{name: "api", center: "CAIC", bounds: colorado, err: errors.New("bad gateway")},
+			&fakeProvider{name: "page", center: "CAIC", bounds: colorado, err: errors.New("page did not load")},
+		)
+		_, err := registry.Forecast(mountain, time.Now())
+		assert.EqualError(t, err, "api: bad gateway\npage: page did not load")
+	})
+
+	t.Run("no provider covers the mountain", func(t *testing.T) {
+		registry := NewAvalancheRegistry(&fakeProvider{name: "api", center: "CAIC", bounds: colorado})
+		_, err := registry.Forecast(MountainCoordinates{MountainID: 8, Lat: 47.4, Lon: -121.4}, time.Now())
+		assert.ErrorContains(t, err, "no avalanche provider covers mountain 8")
+	})
+}
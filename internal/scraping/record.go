@@ -0,0 +1,54 @@
+package scraping
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+
+	"powderhoundgo/internal/fixtures"
+	"powderhoundgo/internal/supabase"
+)
+
+type recorderKey struct{}
+
+func withRecorder(ctx context.Context, recorder *fixtures.Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// Saves the current page to the fixture recorder attached to ctx, if any
+func recordPage(ctx context.Context, label string) {
+	recorder, ok := ctx.Value(recorderKey{}).(*fixtures.Recorder)
+	if !ok {
+		return
+	}
+	if err := recorder.Snapshot(ctx, label); err != nil {
+		log.Printf("Warning: failed to record %s page: %v", label, err)
+	}
+}
+
+// Attaches a recorder when SCRAPE_RECORD_DIR is set so that regular scraping
+// runs can be used to refresh the offline fixtures
+func recorderContextFromEnv(ctx context.Context, mountainName string) context.Context {
+	dir := os.Getenv("SCRAPE_RECORD_DIR")
+	if dir == "" {
+		return ctx
+	}
+	recorder, err := fixtures.NewRecorder(filepath.Join(dir, mountainName), mountainName)
+	if err != nil {
+		log.Printf("Warning: fixture recording disabled: %v", err)
+		return ctx
+	}
+	return withRecorder(ctx, recorder)
+}
+
+// RecordResortFixtures scrapes a resort using the provided config and saves every
+// page it loads to dir so the scrape can be replayed offline
+func RecordResortFixtures(config supabase.ScrapingConfig, dir string) (map[string]interface{}, error) {
+	recorder, err := fixtures.NewRecorder(dir, config.Name)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := scrapeResortConditions(withRecorder(context.Background(), recorder), config)
+	return data, err
+}
@@ -15,56 +15,39 @@ import (
 	"golang.org/x/text/language"
 )
 
-func clickProvidedSelector(ctx context.Context, config supabase.ScrapingConfig) {
-	runChromeDP(ctx,
-		chromedp.WaitVisible(config.ClickSelector),
-		chromedp.Click(config.ClickSelector),
+// Counts the open lift rows on the terrain page for resorts that list each
+// lift's status instead of a total
+func countOpenLifts(ctx context.Context, config supabase.ScrapingConfig) int {
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var openLifts []*cdp.Node
+	err := runChromeDP(tctx,
+		chromedp.WaitReady(config.Terrain.LiftsOpenSelector),
+		chromedp.Nodes(config.Terrain.LiftStatusSelector, &openLifts, chromedp.ByQueryAll),
 	)
+	if err != nil {
+		log.Printf("No open lift nodes found")
+		return 0
+	}
+	return len(openLifts)
 }
 
-func countLiftsAndRuns(ctx context.Context, config supabase.ScrapingConfig) (runsOpen, liftsOpen int, err error) {
+// Counts the open run rows on the terrain page for resorts that list each
+// run's status instead of a total
+func countOpenRuns(ctx context.Context, config supabase.ScrapingConfig) int {
 	tctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	var openLifts, openRuns []*cdp.Node
-	if config.Terrain.RunClickInteraction {
-		var buttonsToClick []*cdp.Node
-		err := runChromeDP(tctx,
-			chromedp.WaitReady(config.Terrain.LiftsOpenSelector),
-			chromedp.Nodes(config.Terrain.LiftStatusSelector, &openLifts, chromedp.ByQueryAll),
-			chromedp.Nodes(config.Terrain.RunClickSelector, &buttonsToClick, chromedp.ByQueryAll),
-		)
-
-		if err != nil {
-			log.Printf("No open lift nodes found")
-			runErr := runChromeDP(ctx,
-				chromedp.Nodes(config.Terrain.RunClickSelector, &buttonsToClick, chromedp.ByQueryAll),
-			)
-			if runErr != nil {
-				return 0, 0, runErr
-			}
-		}
-
-		if len(buttonsToClick) == 0 {
-			log.Panic("No buttons provided to click")
-		}
-		for _, button := range buttonsToClick {
-			runChromeDP(ctx, chromedp.Click(button.FullXPath()))
-		}
-		runChromeDP(ctx,
-			chromedp.Nodes(config.Terrain.RunStatusSelector, &openRuns, chromedp.ByQueryAll),
-		)
-	} else {
-		err := runChromeDP(tctx,
-			chromedp.WaitReady(config.Terrain.LiftsOpenSelector),
-			chromedp.Nodes(config.Terrain.LiftStatusSelector, &openLifts, chromedp.ByQueryAll),
-			chromedp.Nodes(config.Terrain.RunStatusSelector, &openRuns, chromedp.ByQueryAll),
-		)
-		if err != nil {
-			return 0, 0, nil
-		}
+	var openRuns []*cdp.Node
+	err := runChromeDP(tctx,
+		chromedp.Nodes(config.Terrain.RunStatusSelector, &openRuns, chromedp.ByQueryAll),
+	)
+	if err != nil {
+		log.Printf("No open run nodes found")
+		return 0
 	}
-	return len(openRuns), len(openLifts), nil
+	return len(openRuns)
 }
 
 func processTextAndConvertToInt(text string, propertyName string) (int, error) {
@@ -82,14 +65,30 @@ func processTextAndConvertToInt(text string, propertyName string) (int, error) {
 	return value, nil
 }
 
-func processConditions(ctx context.Context, config supabase.ScrapingConfig, conditionsNodes []*cdp.Node) (baseDepth, snow24, snow48 int, snow7Days, seasonTotal, snowpack string, err error) {
-	var baseDepthText, snow24Text, snow48Text string
+// Parses a required snow measurement, converting it from the resort's unit to inches
+func processMeasurementText(text string, propertyName string, unit string) (float64, error) {
+	log.Printf("Parsing measurement %q for property: %s", text, propertyName)
+	if text == "" {
+		return 0, fmt.Errorf("no value found for %s", propertyName)
+	}
+
+	value, err := parseMeasurement(text, unit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", propertyName, err)
+	}
+
+	return value, nil
+}
+
+func processConditions(ctx context.Context, config supabase.ScrapingConfig, conditionsNodes []*cdp.Node) (resortReport, error) {
+	texts := newConditionsText(pageFields(config))
+	hydrationExhausted := false
 
 	// Wait for the elements to be visible first
 	if config.Conditions.WaitForSelector != "" {
 		runChromeDP(ctx, chromedp.WaitVisible(config.Conditions.WaitForSelector))
 	} else {
-		runChromeDP(ctx, chromedp.WaitVisible(config.Conditions.BaseDepthSelector))
+		runChromeDP(ctx, chromedp.WaitVisible(config.Conditions.BaseDepthSelector.String()))
 	}
 
 	log.Printf("Conditions nodes received, processing %d nodes", len(conditionsNodes))
@@ -104,10 +103,7 @@ func processConditions(ctx context.Context, config supabase.ScrapingConfig, cond
 		dataFound := false
 
 		for i := 0; i < maxRetries; i++ {
-			var testText string
-			err := chromedp.Run(ctx,
-				chromedp.Text(config.Conditions.BaseDepthSelector, &testText, chromedp.ByQuery, chromedp.FromNode(node)),
-			)
+			testText, selector := getFieldText(ctx, config.Conditions.BaseDepthSelector, node)
 
 			trimmedText := strings.TrimSpace(testText)
 			// Check that the text contains at least one digit (actual data)
@@ -119,7 +115,7 @@ func processConditions(ctx context.Context, config supabase.ScrapingConfig, cond
 				}
 			}
 
-			if err == nil && hasDigit && trimmedText != "--" && trimmedText != "—" {
+			if selector != "" && hasDigit && !isPlaceholder(trimmedText) {
 				log.Printf("Data detected after %d attempts: '%s'", i+1, trimmedText)
 				dataFound = true
 				break
@@ -135,61 +131,55 @@ func processConditions(ctx context.Context, config supabase.ScrapingConfig, cond
 
 		if !dataFound {
 			log.Printf("Warning: Proceeding without confirmed data hydration")
+			hydrationExhausted = true
 		}
 
-		getTextFromNode(ctx, config.Conditions.SnowpackSelector, node, &snowpack)
-		getTextFromNode(ctx, config.Conditions.SeasonTotalSelector, node, &seasonTotal)
-		getTextFromNode(ctx, config.Conditions.Snow7DaySelector, node, &snow7Days)
-		runChromeDP(ctx,
-			chromedp.Text(config.Conditions.BaseDepthSelector, &baseDepthText, chromedp.ByQuery, chromedp.FromNode(node)),
-			chromedp.Text(config.Conditions.Snow24Selector, &snow24Text, chromedp.ByQuery, chromedp.FromNode(node)),
-			chromedp.Text(config.Conditions.Snow48Selector, &snow48Text, chromedp.ByQuery, chromedp.FromNode(node)),
-		)
+		for _, field := range texts.fields {
+			if !field.selector.IsSet() {
+				continue
+			}
+			if text, selector := getFieldText(ctx, field.selector, node); selector != "" {
+				texts.set(field, text, selector)
+			}
+		}
 	}
 
 	log.Printf("Node processing completed.")
-
-	baseDepth, err = processTextAndConvertToInt(baseDepthText, "base depth")
-	log.Printf("Base Depth: %d", baseDepth)
-	if err != nil {
-		return 0, 0, 0, "", "", "", err
-	}
-
-	snow24, err = processTextAndConvertToInt(snow24Text, "snow past 24 hours")
-	if err != nil {
-		return 0, 0, 0, "", "", "", err
-	}
-
-	snow48, err = processTextAndConvertToInt(snow48Text, "snow past 48 hours")
-	if err != nil {
-		return 0, 0, 0, "", "", "", err
-	}
-
-	log.Printf("Base Depth: %d, Snow 24: %d, Snow 48: %d", baseDepth, snow24, snow48)
-	return baseDepth, snow24, snow48, snow7Days, seasonTotal, snowpack, nil
+	report, err := texts.report(config.Unit)
+	report.diagnostics.HydrationExhausted = hydrationExhausted
+	return report, err
 }
 
-func processTerrain(ctx context.Context, config supabase.ScrapingConfig, terrainNodes []*cdp.Node) (runsOpen, liftsOpen int, err error) {
-	var runsOpenText, liftsOpenText string
+// Each terrain node gets its own timeout so a node missing one of the
+// selectors doesn't starve the nodes after it
+func getTerrainNodeText(ctx context.Context, selector string, node *cdp.Node) (string, error) {
 	tctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
+
+	var text string
+	err := runChromeDP(tctx, chromedp.TextContent(selector, &text, chromedp.ByQuery, chromedp.FromNode(node)))
+	return text, err
+}
+
+func processTerrain(ctx context.Context, config supabase.ScrapingConfig, terrainNodes []*cdp.Node) (terrainCounts, error) {
+	var runsOpenTexts, liftsOpenTexts []string
 	for _, node := range terrainNodes {
-		err := runChromeDP(tctx,
-			chromedp.TextContent(config.Terrain.RunsOpenSelector, &runsOpenText, chromedp.ByQuery, chromedp.FromNode(node)),
-			chromedp.TextContent(config.Terrain.LiftsOpenSelector, &liftsOpenText, chromedp.ByQuery, chromedp.FromNode(node)),
-		)
+		runsOpenText, err := getTerrainNodeText(ctx, config.Terrain.RunsOpenSelector, node)
 		if err != nil {
-			log.Printf("Error getting terrain data: %v", err)
-			liftsOpenText = "0"
+			log.Printf("Error getting runs open: %v", err)
+		} else {
+			runsOpenTexts = append(runsOpenTexts, runsOpenText)
 		}
-	}
-	runsOpenFormatted, err := removeDenominator(runsOpenText)
-	liftsOpenFormatted, err := removeDenominator(liftsOpenText)
 
-	runsOpen, err = convertStringToInt(runsOpenFormatted)
-	liftsOpen, err = convertStringToInt(liftsOpenFormatted)
+		text, err := getTerrainNodeText(ctx, config.Terrain.LiftsOpenSelector, node)
+		if err != nil {
+			log.Printf("Error getting lifts open: %v", err)
+		} else {
+			liftsOpenTexts = append(liftsOpenTexts, text)
+		}
+	}
 
-	return runsOpen, liftsOpen, err
+	return convertTerrainTexts(config, runsOpenTexts, liftsOpenTexts)
 }
 
 func navigateToURL(ctx context.Context, url string) {
@@ -215,107 +205,160 @@ func getTerrainNodes(ctx context.Context, config supabase.ScrapingConfig) []*cdp
 	return terrainNodes
 }
 
-func getTerrainData(ctx context.Context, config supabase.ScrapingConfig) (runsOpen, liftsOpen int, err error) {
-	// Handles cases where the site requires a click to load the terrain data
-	if config.ClickSelector != "" {
-		clickProvidedSelector(ctx, config)
+// Reads the open lift and run totals from the terrain page, counting status
+// rows instead for whichever of the two the config marks as counted
+func extractTerrain(ctx context.Context, config supabase.ScrapingConfig) (terrainCounts, error) {
+	var counts terrainCounts
+	if !config.Terrain.CountLifts || !config.Terrain.CountRuns {
 		terrainNodes := getTerrainNodes(ctx, config)
-		runsOpen, liftsOpen, err = processTerrain(ctx, config, terrainNodes)
-		return runsOpen, liftsOpen, err
+		var err error
+		counts, err = processTerrain(ctx, config, terrainNodes)
+		if err != nil {
+			return counts, err
+		}
 	}
 
-	// Handles cases where the terrain data is on a separate page
-	if config.SeparateURLs {
-		navigateToURL(ctx, config.TerrainURL)
-
-		// Handles cases where an exact count of lifts and runs is not provided
-		// and we need to count them ourselves
-		if config.Terrain.CountLifts {
-			runsOpen, liftsOpen, err = countLiftsAndRuns(ctx, config)
-			return runsOpen, liftsOpen, err
-		}
+	if config.Terrain.CountLifts {
+		counts.liftsOpen = countOpenLifts(ctx, config)
+		counts.record(countDiagnostic("liftsOpen", config.Terrain.LiftStatusSelector, counts.liftsOpen))
+	}
+	if config.Terrain.CountRuns {
+		counts.runsOpen = countOpenRuns(ctx, config)
+		counts.record(countDiagnostic("runsOpen", config.Terrain.RunStatusSelector, counts.runsOpen))
+	}
+	return counts, nil
+}
 
-		terrainNodes := getTerrainNodes(ctx, config)
-		runsOpen, liftsOpen, err = processTerrain(ctx, config, terrainNodes)
-		return runsOpen, liftsOpen, err
+func getTerrainData(ctx context.Context, config supabase.ScrapingConfig) (terrainCounts, error) {
+	// Steps that reveal the terrain data, e.g. navigating to a separate terrain
+	// page or clicking open a lift status panel
+	if err := runSteps(ctx, resolveSteps(config), supabase.StageTerrain); err != nil {
+		return terrainCounts{}, err
 	}
+	return extractTerrain(ctx, config)
+}
 
-	// Handles cases where the terrain data is on the same page as the conditions data
-	// but the exact count of lifts and runs is not provided
-	if config.Terrain.CountLifts {
-		runsOpen, liftsOpen, err = countLiftsAndRuns(ctx, config)
-		return runsOpen, liftsOpen, err
+// ScrapeResortData scrapes a resort's conditions into a resort_conditions row.
+// The diagnostics describe how each field was read and are returned even when
+// the scrape fails
+func ScrapeResortData(mountainName string, config supabase.ScrapingConfig) (map[string]interface{}, supabase.ScrapeDiagnostics, error) {
+	// Server-rendered pages and JSON feeds can be scraped without starting a browser
+	switch config.Engine {
+	case supabase.EngineHTTP:
+		return scrapeResortConditionsHTTP(config)
+	case supabase.EngineJSON:
+		return scrapeResortConditionsJSON(config)
 	}
 
-	// Handles cases where the terrain data is on the same page as the conditions data
-	// with no special interactions required
-	terrainNodes := getTerrainNodes(ctx, config)
-	runsOpen, liftsOpen, err = processTerrain(ctx, config, terrainNodes)
-	return runsOpen, liftsOpen, err
+	ctx := recorderContextFromEnv(context.Background(), mountainName)
+	return scrapeResortConditions(ctx, config)
 }
 
-func ScrapeResortData(mountainName *string) (map[string]interface{}, error) {
-	supabaseClient := supabase.NewSupabaseService()
-	config := supabaseClient.GetConfigByName(*mountainName)
+// Raw values extracted from a resort's pages, before they are formatted into a resort_conditions row
+type resortReport struct {
+	// Measurements in inches
+	baseDepth   float64
+	snow24      float64
+	snow48      float64
+	snow7Days   float64
+	seasonTotal float64
+	snowpack    string
+	runsOpen    int
+	liftsOpen   int
+	// Selector each conditions value was read from, keyed by config field
+	matchedSelectors map[string]string
+	diagnostics      supabase.ScrapeDiagnostics
+}
 
-	resortConditions := map[string]interface{}{
-		"mountain_id":   config.ID,
-		"display_name":  config.Name,
-		"base_depth":    0,
-		"snow_past_24h": 0,
-		"snow_past_48h": 0,
-		"lifts_open":    0,
-		"runs_open":     0,
-		"updated_at":    time.Now(),
+func (r *resortReport) measurement(name string) *float64 {
+	switch name {
+	case "baseDepth":
+		return &r.baseDepth
+	case "snow24":
+		return &r.snow24
+	case "snow48":
+		return &r.snow48
+	case "snow7Day":
+		return &r.snow7Days
+	case "seasonTotal":
+		return &r.seasonTotal
 	}
+	panic("unknown measurement " + name)
+}
 
-	ctx, cancel := chromedp.NewContext(context.Background(), chromedp.WithLogf(log.Printf))
+func (r *resortReport) setTerrain(counts terrainCounts) {
+	r.runsOpen = counts.runsOpen
+	r.liftsOpen = counts.liftsOpen
+	r.diagnostics.Fields = append(r.diagnostics.Fields, counts.diagnostics...)
+}
 
+func scrapeResortConditions(parent context.Context, config supabase.ScrapingConfig) (map[string]interface{}, supabase.ScrapeDiagnostics, error) {
+	tabCtx, cancel, err := newBrowserContext(parent)
+	if err != nil {
+		return nil, supabase.ScrapeDiagnostics{}, err
+	}
 	defer cancel()
+	browserLog := listenBrowserLog(tabCtx)
 
-	ctx, cancel = context.WithTimeout(ctx, 180*time.Second)
-	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(tabCtx, 180*time.Second)
+	defer cancelTimeout()
+
+	data, diagnostics, err := scrapeResortPages(ctx, config)
+	if err != nil {
+		return nil, diagnostics, captureBrowserFailure(tabCtx, config.Name, browserLog, err)
+	}
+	return data, diagnostics, nil
+}
 
+func scrapeResortPages(ctx context.Context, config supabase.ScrapingConfig) (map[string]interface{}, supabase.ScrapeDiagnostics, error) {
 	navigateToURL(ctx, config.ConditionsURL)
+	if err := runSteps(ctx, resolveSteps(config), supabase.StageConditions); err != nil {
+		return nil, supabase.ScrapeDiagnostics{}, err
+	}
 	conditionsNodes := getConditionsNodes(ctx, config)
-	baseDepth, snow24, snow48, snow7Days, seasonTotal, snowpack, err := processConditions(ctx, config, conditionsNodes)
+	report, err := processConditions(ctx, config, conditionsNodes)
 	if err != nil {
-		return nil, err
+		return nil, report.diagnostics, err
 	}
-	runsOpen, liftsOpen, err := getTerrainData(ctx, config)
+	recordPage(ctx, "conditions")
+
+	counts, err := getTerrainData(ctx, config)
+	report.setTerrain(counts)
+	recordPage(ctx, "terrain")
 	if err != nil {
-		return nil, err
+		return nil, report.diagnostics, err
 	}
 
-	resortConditions["base_depth"] = baseDepth
-	resortConditions["snow_past_24h"] = snow24
-	resortConditions["snow_past_48h"] = snow48
-	resortConditions["runs_open"] = runsOpen
-	resortConditions["lifts_open"] = liftsOpen
+	return buildResortConditions(config, report), report.diagnostics, nil
+}
+
+func buildResortConditions(config supabase.ScrapingConfig, report resortReport) map[string]interface{} {
+	resortConditions := map[string]interface{}{
+		"mountain_id":   config.ID,
+		"display_name":  config.Name,
+		"base_depth":    roundInches(report.baseDepth),
+		"snow_past_24h": roundInches(report.snow24),
+		"snow_past_48h": roundInches(report.snow48),
+		"lifts_open":    report.liftsOpen,
+		"runs_open":     report.runsOpen,
+		"updated_at":    time.Now(),
+	}
 
-	if config.Conditions.SnowpackSelector != "" {
-		lowercaseSnowpack := cases.Lower(language.English, cases.Compact).String(snowpack)
+	if config.Conditions.SnowpackSelector.IsSet() || config.API.Snowpack != "" {
+		lowercaseSnowpack := cases.Lower(language.English, cases.Compact).String(report.snowpack)
 		formattedSnowpack := cases.Title(language.English, cases.Compact).String(lowercaseSnowpack)
 		resortConditions["snow_type"] = formattedSnowpack
 	}
-	if config.Conditions.SeasonTotalSelector != "" {
-		result, err := convertStringToInt(seasonTotal)
-		if err != nil {
-			log.Printf("Warning: failed to convert season total to int: %v, using 0", err)
-			resortConditions["snow_total"] = 0
-		} else {
-			resortConditions["snow_total"] = result
-		}
+	if config.Conditions.SeasonTotalSelector.IsSet() || config.API.SeasonTotal != "" {
+		resortConditions["snow_total"] = roundInches(report.seasonTotal)
 	}
-	if config.Conditions.Snow7DaySelector != "" {
-		result, err := convertStringToInt(snow7Days)
-		if err != nil {
-			log.Printf("Warning: failed to convert 7 day snowfall to int: %v, using 0", err)
-			resortConditions["snow_past_week"] = 0
-		} else {
-			resortConditions["snow_past_week"] = result
-		}
+	if config.Conditions.Snow7DaySelector.IsSet() || config.API.Snow7Day != "" {
+		resortConditions["snow_past_week"] = roundInches(report.snow7Days)
+	}
+	// Shows when a resort's markup has drifted onto a fallback selector
+	if len(report.matchedSelectors) > 0 {
+		resortConditions["matched_selectors"] = report.matchedSelectors
 	}
 
-	return resortConditions, err
+	return resortConditions
 }
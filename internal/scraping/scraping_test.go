@@ -0,0 +1,219 @@
+package scraping
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"powderhoundgo/internal/configsource"
+	"powderhoundgo/internal/fixtures"
+	"powderhoundgo/internal/supabase"
+
+	"github.com/chromedp/chromedp"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	configDir  = "../../config"
+	fixtureDir = "testdata/fixtures"
+)
+
+var resortFixtureTests = []struct {
+	mountain  string
+	baseDepth float64
+	snow24    float64
+	snow48    float64
+	liftsOpen int
+	runsOpen  int
+}{
+	{"a-basin", 38, 3, 5, 9, 112},
+	{"aspen-highlands", 52, 4, 7, 5, 118},
+	{"aspen-mountain", 46, 2, 3, 8, 76},
+	{"aspen-snowmass", 55, 6, 9, 17, 92},
+	{"beaver-creek", 42, 5, 8, 19, 141},
+	{"breckenridge", 49, 3, 6, 31, 166},
+	{"copper-mountain", 47, 3, 6, 19, 98},
+	{"crested-butte", 39, 1, 2, 12, 104},
+	{"eldora", 33, 4, 7, 8, 41},
+	{"keystone", 36, 2, 4, 15, 98},
+	{"loveland", 61, 2, 4, 7, 72},
+	{"monarch", 44, 2, 4, 5, 54},
+	{"powderhorn", 38, 2, 5, 3, 7},
+	{"purgatory", 41, 2, 4, 8, 71},
+	{"steamboat", 58, 3, 7, 18, 152},
+	{"sunlight-mountain", 44, 2, 5, 3, 61},
+	{"telluride", 49, 4, 8, 15, 118},
+	{"vail", 51, 6, 10, 4, 6},
+	{"winter-park", 63, 5, 9, 22, 139},
+}
+
+// Terrain option combinations run against the terrain-options fixture, which
+// splits its trail report into alpine and nordic sections and lists each lift
+// and trail status
+var terrainOptionTests = []struct {
+	name      string
+	modify    func(*supabase.TerrainConfig)
+	liftsOpen int
+	runsOpen  int
+}{
+	{"last terrain node wins", func(t *supabase.TerrainConfig) {}, 8, 12},
+	{"sum runs from multiple sources", func(t *supabase.TerrainConfig) {
+		t.SumRunsFromMultipleSources = true
+	}, 8, 53},
+	{"count runs", func(t *supabase.TerrainConfig) {
+		t.CountRuns = true
+	}, 8, 4},
+	{"count lifts and sum runs", func(t *supabase.TerrainConfig) {
+		t.CountLifts = true
+		t.SumRunsFromMultipleSources = true
+	}, 4, 53},
+	{"count lifts and runs", func(t *supabase.TerrainConfig) {
+		t.CountLifts = true
+		t.CountRuns = true
+	}, 4, 4},
+}
+
+func terrainOptionsConfig(server *fixtures.ReplayServer, modify func(*supabase.TerrainConfig)) supabase.ScrapingConfig {
+	config := supabase.ScrapingConfig{
+		ID:            98,
+		Name:          "Terrain Options Resort",
+		ConditionsURL: server.ReplayURL("https://www.example-resort.com/mountain-report"),
+		Conditions: supabase.ConditionsConfig{
+			ConditionsSelector: ".snow-report",
+			BaseDepthSelector:  supabase.NewFieldSelector(".base > .value"),
+			Snow24Selector:     supabase.NewFieldSelector(".snow-24 > .value"),
+			Snow48Selector:     supabase.NewFieldSelector(".snow-48 > .value"),
+		},
+		Terrain: supabase.TerrainConfig{
+			TerrainSelector:    ".terrain-area",
+			RunsOpenSelector:   ".trails-open",
+			LiftsOpenSelector:  ".lifts-open",
+			LiftStatusSelector: ".lift-row > .status-open",
+			RunStatusSelector:  ".trail-row > .status-open",
+		},
+	}
+	modify(&config.Terrain)
+	return config
+}
+
+func loadTestConfig(t *testing.T, mountain string) supabase.ScrapingConfig {
+	t.Helper()
+	config, err := configsource.Load(configsource.NewDirSource(configDir), mountain)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	return config
+}
+
+// Starts a headless browser that can only reach the local replay server.
+// Tests are skipped when no Chrome installation is available, except in CI
+// where the fixtures would otherwise go untested
+func newReplayBrowser(t *testing.T) context.Context {
+	t.Helper()
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("host-resolver-rules", "MAP * ~NOTFOUND, EXCLUDE 127.0.0.1"),
+	)
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	ctx, cancelCtx := chromedp.NewContext(allocCtx)
+	t.Cleanup(func() {
+		cancelCtx()
+		cancelAlloc()
+	})
+
+	if err := chromedp.Run(ctx); err != nil {
+		if os.Getenv("CI") != "" {
+			t.Fatalf("chrome is required to replay fixtures in CI: %v", err)
+		}
+		t.Skipf("chrome is not available: %v", err)
+	}
+	return ctx
+}
+
+func TestEveryConfigHasFixture(t *testing.T) {
+	configs, err := filepath.Glob(filepath.Join(configDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tested := map[string]bool{}
+	for _, tt := range resortFixtureTests {
+		tested[tt.mountain] = true
+	}
+
+	for _, configPath := range configs {
+		mountain := strings.TrimSuffix(filepath.Base(configPath), ".json")
+		_, err := fixtures.ReadManifest(filepath.Join(fixtureDir, mountain))
+		assert.NoError(t, err, "missing fixture for %s", mountain)
+		assert.True(t, tested[mountain], "missing fixture test case for %s", mountain)
+	}
+}
+
+func TestScrapeResortFixtures(t *testing.T) {
+	browserCtx := newReplayBrowser(t)
+
+	for _, tt := range resortFixtureTests {
+		t.Run(tt.mountain, func(t *testing.T) {
+			server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, tt.mountain))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer server.Close()
+
+			config := server.RewriteConfig(loadTestConfig(t, tt.mountain))
+
+			tabCtx, cancelTab := chromedp.NewContext(browserCtx)
+			defer cancelTab()
+			ctx, cancel := context.WithTimeout(tabCtx, 60*time.Second)
+			defer cancel()
+
+			navigateToURL(ctx, config.ConditionsURL)
+			if err := runSteps(ctx, resolveSteps(config), supabase.StageConditions); err != nil {
+				t.Fatal(err)
+			}
+			conditionsNodes := getConditionsNodes(ctx, config)
+			report, err := processConditions(ctx, config, conditionsNodes)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.baseDepth, report.baseDepth, "base depth")
+				assert.Equal(t, tt.snow24, report.snow24, "snow past 24h")
+				assert.Equal(t, tt.snow48, report.snow48, "snow past 48h")
+			}
+
+			counts, err := getTerrainData(ctx, config)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.liftsOpen, counts.liftsOpen, "lifts open")
+				assert.Equal(t, tt.runsOpen, counts.runsOpen, "runs open")
+			}
+		})
+	}
+}
+
+func TestTerrainOptionsFixture(t *testing.T) {
+	browserCtx := newReplayBrowser(t)
+
+	server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, "terrain-options"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	for _, tt := range terrainOptionTests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := terrainOptionsConfig(server, tt.modify)
+
+			tabCtx, cancelTab := chromedp.NewContext(browserCtx)
+			defer cancelTab()
+			ctx, cancel := context.WithTimeout(tabCtx, 60*time.Second)
+			defer cancel()
+
+			navigateToURL(ctx, config.ConditionsURL)
+			counts, err := getTerrainData(ctx, config)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.liftsOpen, counts.liftsOpen, "lifts open")
+				assert.Equal(t, tt.runsOpen, counts.runsOpen, "runs open")
+			}
+		})
+	}
+}
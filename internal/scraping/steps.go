@@ -0,0 +1,142 @@
+package scraping
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/chromedp"
+)
+
+// Returns the config's steps, translating the legacy separateURLs, clickSelector
+// and runClickInteraction flags into steps for configs that don't declare any
+func resolveSteps(config supabase.ScrapingConfig) []supabase.Step {
+	if len(config.Steps) > 0 {
+		return config.Steps
+	}
+
+	var steps []supabase.Step
+	// A click selector reveals the terrain on the conditions page, so the
+	// terrain URL is never visited, even with separateURLs set
+	if config.SeparateURLs && config.ClickSelector == "" {
+		steps = append(steps, supabase.Step{Action: supabase.StepNavigate, Before: supabase.StageTerrain, URL: config.TerrainURL})
+	}
+	if config.ClickSelector != "" {
+		steps = append(steps, supabase.Step{Action: supabase.StepClick, Before: supabase.StageTerrain, Selector: config.ClickSelector})
+	}
+	if config.Terrain.RunClickInteraction {
+		steps = append(steps, supabase.Step{Action: supabase.StepClickAll, Before: supabase.StageTerrain, Selector: config.Terrain.RunClickSelector})
+	}
+	return steps
+}
+
+func stepStage(step supabase.Step) string {
+	if step.Before == "" {
+		return supabase.StageConditions
+	}
+	return step.Before
+}
+
+// Runs the steps that come before the given extraction stage, in order
+func runSteps(ctx context.Context, steps []supabase.Step, stage string) error {
+	for _, step := range steps {
+		if stepStage(step) != stage {
+			continue
+		}
+		log.Printf("Running %s step before %s", step.Action, stage)
+		if err := runStep(ctx, step); err != nil {
+			return fmt.Errorf("%s step failed: %w", step.Action, err)
+		}
+	}
+	return nil
+}
+
+func runStep(ctx context.Context, step supabase.Step) error {
+	switch step.Action {
+	case supabase.StepNavigate:
+		return runChromeDP(ctx, chromedp.EmulateViewport(1200, 1000), chromedp.Navigate(step.URL))
+	case supabase.StepClick:
+		err := runChromeDP(ctx,
+			chromedp.WaitVisible(step.Selector),
+			chromedp.Click(step.Selector),
+		)
+		recordPage(ctx, "click")
+		return err
+	case supabase.StepClickAll:
+		return clickAll(ctx, step.Selector)
+	case supabase.StepWaitVisible:
+		return runChromeDP(ctx, chromedp.WaitVisible(step.Selector))
+	case supabase.StepScroll:
+		// Without a selector the page is scrolled to the bottom to trigger lazy loading
+		if step.Selector == "" {
+			return runChromeDP(ctx, chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil))
+		}
+		return runChromeDP(ctx, chromedp.ScrollIntoView(step.Selector))
+	case supabase.StepSelectTab:
+		err := selectTab(ctx, step.Selector, step.Text)
+		recordPage(ctx, "select-tab")
+		return err
+	case supabase.StepSleep:
+		duration, err := time.ParseDuration(step.Duration)
+		if err != nil {
+			return err
+		}
+		return runChromeDP(ctx, chromedp.Sleep(duration))
+	default:
+		return fmt.Errorf("unknown step action %q", step.Action)
+	}
+}
+
+func clickAll(ctx context.Context, selector string) error {
+	var buttonsToClick []*cdp.Node
+	err := runChromeDP(ctx,
+		chromedp.Nodes(selector, &buttonsToClick, chromedp.ByQueryAll),
+	)
+	if err != nil {
+		return err
+	}
+	if len(buttonsToClick) == 0 {
+		return fmt.Errorf("no elements found to click for %s", selector)
+	}
+	for _, button := range buttonsToClick {
+		runChromeDP(ctx, chromedp.Click(button.FullXPath()))
+	}
+	return nil
+}
+
+// Clicks the element matching selector whose text is the tab's label, ignoring case
+func selectTab(ctx context.Context, selector, label string) error {
+	selectorJSON, _ := json.Marshal(selector)
+	labelJSON, _ := json.Marshal(label)
+	script := fmt.Sprintf(`(() => {
+		const label = %s.trim().toLowerCase();
+		for (const tab of document.querySelectorAll(%s)) {
+			if (tab.textContent.trim().toLowerCase() === label) {
+				tab.click();
+				return true;
+			}
+		}
+		return false;
+	})()`, labelJSON, selectorJSON)
+
+	err := runChromeDP(ctx,
+		chromedp.WaitVisible(selector),
+	)
+	if err != nil {
+		return err
+	}
+
+	var selected bool
+	if err := runChromeDP(ctx, chromedp.Evaluate(script, &selected)); err != nil {
+		return err
+	}
+	if !selected {
+		return fmt.Errorf("no tab labelled %q found for %s", label, selector)
+	}
+	return nil
+}
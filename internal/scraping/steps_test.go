@@ -0,0 +1,182 @@
+package scraping
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"powderhoundgo/internal/fixtures"
+	"powderhoundgo/internal/supabase"
+
+	"github.com/chromedp/chromedp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSteps(t *testing.T) {
+	tests := []struct {
+		name   string
+		config supabase.ScrapingConfig
+		want   []supabase.Step
+	}{
+		{"no interactions", supabase.ScrapingConfig{}, nil},
+		{"separate terrain page", supabase.ScrapingConfig{
+			SeparateURLs: true,
+			TerrainURL:   "https://example.com/terrain",
+		}, []supabase.Step{
+			{Action: supabase.StepNavigate, Before: supabase.StageTerrain, URL: "https://example.com/terrain"},
+		}},
+		{"click selector", supabase.ScrapingConfig{
+			ClickSelector: "button.lifts",
+		}, []supabase.Step{
+			{Action: supabase.StepClick, Before: supabase.StageTerrain, Selector: "button.lifts"},
+		}},
+		{"click selector on a separate terrain page config", supabase.ScrapingConfig{
+			SeparateURLs:  true,
+			TerrainURL:    "https://example.com/terrain",
+			ClickSelector: "button.lifts",
+		}, []supabase.Step{
+			{Action: supabase.StepClick, Before: supabase.StageTerrain, Selector: "button.lifts"},
+		}},
+		{"run click interaction", supabase.ScrapingConfig{
+			SeparateURLs: true,
+			TerrainURL:   "https://example.com/terrain",
+			Terrain: supabase.TerrainConfig{
+				RunClickInteraction: true,
+				RunClickSelector:    "a.toggle",
+			},
+		}, []supabase.Step{
+			{Action: supabase.StepNavigate, Before: supabase.StageTerrain, URL: "https://example.com/terrain"},
+			{Action: supabase.StepClickAll, Before: supabase.StageTerrain, Selector: "a.toggle"},
+		}},
+		{"declared steps replace the legacy flags", supabase.ScrapingConfig{
+			ClickSelector: "button.lifts",
+			Steps: []supabase.Step{
+				{Action: supabase.StepSelectTab, Before: supabase.StageTerrain, Selector: ".tab", Text: "Lifts"},
+			},
+		}, []supabase.Step{
+			{Action: supabase.StepSelectTab, Before: supabase.StageTerrain, Selector: ".tab", Text: "Lifts"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resolveSteps(tt.config))
+		})
+	}
+}
+
+func stepsFixtureConfig(server *fixtures.ReplayServer, steps []supabase.Step) supabase.ScrapingConfig {
+	return server.RewriteConfig(supabase.ScrapingConfig{
+		ID:            97,
+		Name:          "Steps Resort",
+		ConditionsURL: "https://www.example-resort.com/snow-report",
+		Conditions: supabase.ConditionsConfig{
+			ConditionsSelector: ".snow-report",
+			BaseDepthSelector:  supabase.NewFieldSelector(".base > .value"),
+			Snow24Selector:     supabase.NewFieldSelector(".snow-24 > .value"),
+			Snow48Selector:     supabase.NewFieldSelector(".snow-48 > .value"),
+		},
+		Terrain: supabase.TerrainConfig{
+			CountRuns:         true,
+			TerrainSelector:   ".terrain-summary",
+			LiftsOpenSelector: ".lifts-open",
+			RunStatusSelector: ".trail-row > .status-open",
+		},
+		Steps: steps,
+	})
+}
+
+var fixtureSteps = []supabase.Step{
+	{Action: supabase.StepWaitVisible, Selector: ".snow-report"},
+	{Action: supabase.StepNavigate, Before: supabase.StageTerrain, URL: "https://www.example-resort.com/lifts-and-trails"},
+	{Action: supabase.StepScroll, Before: supabase.StageTerrain},
+	{Action: supabase.StepSleep, Before: supabase.StageTerrain, Duration: "100ms"},
+	{Action: supabase.StepClickAll, Before: supabase.StageTerrain, Selector: ".trail-group > .toggle"},
+}
+
+func TestStepsFixture(t *testing.T) {
+	browserCtx := newReplayBrowser(t)
+
+	server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, "steps"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	steps := append(fixtureSteps[:len(fixtureSteps):len(fixtureSteps)],
+		supabase.Step{Action: supabase.StepSelectTab, Before: supabase.StageTerrain, Selector: ".terrain-tabs > .tab", Text: "trails"},
+	)
+	config := stepsFixtureConfig(server, steps)
+
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx)
+	defer cancelTab()
+	ctx, cancel := context.WithTimeout(tabCtx, 60*time.Second)
+	defer cancel()
+
+	data, _, err := scrapeResortConditions(ctx, config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 45.0, data["base_depth"], "base depth")
+	assert.Equal(t, 6, data["lifts_open"], "lifts open")
+	assert.Equal(t, 2, data["runs_open"], "runs open")
+
+	t.Run("fails when a tab is missing", func(t *testing.T) {
+		missing := stepsFixtureConfig(server, []supabase.Step{
+			{Action: supabase.StepSelectTab, Selector: ".snow-report", Text: "Webcams"},
+		})
+		_, _, err := scrapeResortConditions(ctx, missing)
+		assert.Error(t, err)
+	})
+}
+
+// Eldora sets separateURLs and a terrain URL, but its terrain is revealed by
+// clicking on the conditions page. Only the conditions page is recorded
+func TestEldoraClickFixture(t *testing.T) {
+	browserCtx := newReplayBrowser(t)
+
+	server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, "eldora"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	config := server.RewriteConfig(loadTestConfig(t, "eldora"))
+
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx)
+	defer cancelTab()
+	ctx, cancel := context.WithTimeout(tabCtx, 60*time.Second)
+	defer cancel()
+
+	data, _, err := scrapeResortConditions(ctx, config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 33.0, data["base_depth"], "base depth")
+	assert.Equal(t, 8, data["lifts_open"], "lifts open")
+	assert.Equal(t, 41, data["runs_open"], "runs open")
+}
+
+func TestStepsFixtureHTTP(t *testing.T) {
+	server, err := fixtures.NewReplayServer(filepath.Join(fixtureDir, "steps"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	data, _, err := scrapeResortConditionsHTTP(stepsFixtureConfig(server, fixtureSteps))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 45.0, data["base_depth"], "base depth")
+	assert.Equal(t, 6, data["lifts_open"], "lifts open")
+	assert.Equal(t, 2, data["runs_open"], "runs open")
+
+	t.Run("rejects interactions that need a browser", func(t *testing.T) {
+		_, _, err := scrapeResortConditionsHTTP(stepsFixtureConfig(server, []supabase.Step{
+			{Action: supabase.StepSelectTab, Before: supabase.StageTerrain, Selector: ".terrain-tabs > .tab", Text: "Trails"},
+		}))
+		assert.Error(t, err)
+	})
+}
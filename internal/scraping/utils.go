@@ -8,7 +8,8 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/chromedp/cdproto/cdp"
+	"powderhoundgo/internal/supabase"
+
 	"github.com/chromedp/chromedp"
 )
 
@@ -33,10 +34,26 @@ func removeDenominator(input string) (string, error) {
 	return result, err
 }
 
+// Resorts show a dash or "N/A" in place of a value they aren't reporting, and
+// feeds a null, which jsonPathText formats as "null"
+func isPlaceholder(text string) bool {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "--", "—", "n/a", "null":
+		return true
+	}
+	return false
+}
+
+// Reports whether a count's text has no number in it, e.g. a placeholder or
+// "N/A", which convertStringToInt treats as 0
+func countDefaults(text string) bool {
+	return !strings.ContainsAny(text, "0123456789")
+}
+
 func convertStringToInt(input string) (int, error) {
 	// Handle special cases like "--" which should be treated as 0
 	trimmedInput := strings.TrimSpace(input)
-	if trimmedInput == "" || trimmedInput == "--" || trimmedInput == "—" {
+	if trimmedInput == "" || isPlaceholder(trimmedInput) {
 		return 0, nil
 	}
 
@@ -59,6 +76,34 @@ func convertStringToInt(input string) (int, error) {
 	return result, nil
 }
 
+// Converts an open count that may include its total (e.g. "12 of 24") to an int
+func convertTerrainCount(input string) (int, error) {
+	formatted, err := removeDenominator(input)
+	if err != nil {
+		return 0, err
+	}
+	return convertStringToInt(formatted)
+}
+
+// Combines the runs open text read from each terrain node. Resorts that split
+// their trail report into several sections (e.g. alpine and nordic) set
+// sumRunsFromMultipleSources to add them together, otherwise the last node wins
+func combineRunsOpen(config supabase.ScrapingConfig, runsOpenTexts []string) (int, error) {
+	runsOpen := 0
+	for _, text := range runsOpenTexts {
+		count, err := convertTerrainCount(text)
+		if err != nil {
+			return 0, err
+		}
+		if config.Terrain.SumRunsFromMultipleSources {
+			runsOpen += count
+		} else {
+			runsOpen = count
+		}
+	}
+	return runsOpen, nil
+}
+
 func runChromeDP(ctx context.Context, tasks ...chromedp.Action) error {
 	log.Printf("Running ChromeDP tasks: %v", tasks)
 	err := chromedp.Run(ctx, tasks...)
@@ -67,10 +112,3 @@ func runChromeDP(ctx context.Context, tasks ...chromedp.Action) error {
 	}
 	return nil
 }
-
-func getTextFromNode(ctx context.Context, selector string, node *cdp.Node, result *string) {
-	log.Printf("Getting text from node with selector: %s", selector)
-	if selector != "" {
-		runChromeDP(ctx, chromedp.Text(selector, result, chromedp.ByQuery, chromedp.FromNode(node)))
-	}
-}
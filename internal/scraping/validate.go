@@ -0,0 +1,115 @@
+package scraping
+
+import (
+	"fmt"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+)
+
+const (
+	// Default for ValidationConfig.MaxBaseDepthChange, in inches
+	defaultMaxBaseDepthChange = 24
+	// Older snapshots, e.g. the last row of the previous season, aren't used
+	// to judge the base depth
+	baseDepthComparisonWindow = 48 * time.Hour
+)
+
+// ValidateConditions checks a resort_conditions row built by a scrape against
+// the resort's previous row and the rules every snow report should follow.
+// It returns the reasons the row is implausible, or nil when it can be
+// upserted. previous is nil before a resort's first upsert
+func ValidateConditions(config supabase.ScrapingConfig, data map[string]interface{}, previous *supabase.ResortConditions) []string {
+	var reasons []string
+	add := func(format string, args ...interface{}) {
+		reasons = append(reasons, fmt.Sprintf(format, args...))
+	}
+
+	baseDepth, _ := conditionsValue(data, "base_depth")
+	snow48, _ := conditionsValue(data, "snow_past_48h")
+
+	// Each snowfall window should hold at least as much as the shorter ones.
+	// Optional totals of 0 are treated as not reported, since resorts often
+	// show a placeholder there
+	windows := []struct {
+		field string
+		name  string
+	}{
+		{"snow_past_24h", "24 hour snowfall"},
+		{"snow_past_48h", "48 hour snowfall"},
+		{"snow_past_week", "7 day snowfall"},
+		{"snow_total", "season total"},
+	}
+	longest := 0.0
+	longestName := ""
+	for _, window := range windows {
+		value, ok := conditionsValue(data, window.field)
+		if !ok || (value == 0 && (window.field == "snow_past_week" || window.field == "snow_total")) {
+			continue
+		}
+		if value < longest {
+			add("%s of %g\" is less than the %s of %g\"", window.name, value, longestName, longest)
+		}
+		if value > longest {
+			longest = value
+			longestName = window.name
+		}
+	}
+
+	for _, value := range []struct {
+		field string
+		name  string
+	}{
+		{"base_depth", "base depth"},
+		{"snow_past_24h", "24 hour snowfall"},
+		{"snow_past_48h", "48 hour snowfall"},
+		{"lifts_open", "lifts open"},
+		{"runs_open", "runs open"},
+	} {
+		if v, ok := conditionsValue(data, value.field); ok && v < 0 {
+			add("%s of %g is negative", value.name, v)
+		}
+	}
+
+	if liftsOpen, ok := conditionsValue(data, "lifts_open"); ok && config.Validation.TotalLifts > 0 && liftsOpen > float64(config.Validation.TotalLifts) {
+		add("%g lifts open but the resort only has %d", liftsOpen, config.Validation.TotalLifts)
+	}
+	if runsOpen, ok := conditionsValue(data, "runs_open"); ok && config.Validation.TotalRuns > 0 && runsOpen > float64(config.Validation.TotalRuns) {
+		add("%g runs open but the resort only has %d", runsOpen, config.Validation.TotalRuns)
+	}
+
+	// A base that disappears is a failed read however old the previous row
+	// is, the resort keeps its last good conditions until a real one comes in
+	baseCleared := previous != nil && previous.BaseDepth > 0 && baseDepth == 0
+	if baseCleared {
+		add("base depth dropped from %g\" to 0", previous.BaseDepth)
+	}
+
+	if previous != nil && time.Since(previous.UpdatedAt) < baseDepthComparisonWindow {
+		maxChange := float64(config.Validation.MaxBaseDepthChange)
+		if maxChange <= 0 {
+			maxChange = defaultMaxBaseDepthChange
+		}
+		// New snow can legitimately build the base, so it's allowed on top of the threshold
+		change := baseDepth - previous.BaseDepth
+		if change > maxChange+snow48 {
+			add("base depth rose from %g\" to %g\" with only %g\" of new snow", previous.BaseDepth, baseDepth, snow48)
+		}
+		if -change > maxChange && !baseCleared {
+			add("base depth dropped from %g\" to %g\"", previous.BaseDepth, baseDepth)
+		}
+	}
+
+	return reasons
+}
+
+// Snow measurements are float64 inches and lift and run counts are ints
+func conditionsValue(data map[string]interface{}, field string) (float64, bool) {
+	switch value := data[field].(type) {
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	}
+	return 0, false
+}
@@ -0,0 +1,84 @@
+package scraping
+
+import (
+	"testing"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConditions(t *testing.T) {
+	config := supabase.ScrapingConfig{
+		Name:       "Validation Resort",
+		Validation: supabase.ValidationConfig{TotalLifts: 20, TotalRuns: 150},
+	}
+	row := func(modify func(map[string]interface{})) map[string]interface{} {
+		data := map[string]interface{}{
+			"base_depth":     48,
+			"snow_past_24h":  3,
+			"snow_past_48h":  6,
+			"snow_past_week": 14,
+			"snow_total":     188,
+			"lifts_open":     18,
+			"runs_open":      120,
+		}
+		if modify != nil {
+			modify(data)
+		}
+		return data
+	}
+	previous := &supabase.ResortConditions{BaseDepth: 45, UpdatedAt: time.Now().Add(-time.Hour)}
+
+	tests := []struct {
+		name     string
+		data     map[string]interface{}
+		previous *supabase.ResortConditions
+		want     int
+	}{
+		{"plausible", row(nil), previous, 0},
+		{"first scrape", row(nil), nil, 0},
+		{"24 hours more than 48 hours", row(func(d map[string]interface{}) { d["snow_past_24h"] = 8 }), previous, 1},
+		{"48 hours more than the week", row(func(d map[string]interface{}) { d["snow_past_week"] = 4 }), previous, 1},
+		{"week more than the season", row(func(d map[string]interface{}) { d["snow_total"] = 10 }), previous, 1},
+		{"unreported totals are skipped", row(func(d map[string]interface{}) {
+			d["snow_past_week"] = 0
+			d["snow_total"] = 0
+		}), previous, 0},
+		{"missing optional totals", row(func(d map[string]interface{}) {
+			delete(d, "snow_past_week")
+			delete(d, "snow_total")
+		}), previous, 0},
+		{"too many lifts", row(func(d map[string]interface{}) { d["lifts_open"] = 21 }), previous, 1},
+		{"too many runs", row(func(d map[string]interface{}) { d["runs_open"] = 151 }), previous, 1},
+		{"base depth drops to 0", row(func(d map[string]interface{}) { d["base_depth"] = 0 }), previous, 1},
+		{"base depth jumps", row(func(d map[string]interface{}) { d["base_depth"] = 90 }), previous, 1},
+		{"new snow builds the base", row(func(d map[string]interface{}) {
+			d["base_depth"] = 80
+			d["snow_past_24h"] = 20
+			d["snow_past_48h"] = 30
+			d["snow_past_week"] = 30
+		}), previous, 0},
+		{"stale previous row", row(func(d map[string]interface{}) { d["base_depth"] = 20 }),
+			&supabase.ResortConditions{BaseDepth: 60, UpdatedAt: time.Now().AddDate(0, -6, 0)}, 0},
+		{"base depth drops to 0 after a stale row", row(func(d map[string]interface{}) { d["base_depth"] = 0 }),
+			&supabase.ResortConditions{BaseDepth: 60, UpdatedAt: time.Now().AddDate(0, -6, 0)}, 1},
+		{"base depth stays at 0", row(func(d map[string]interface{}) { d["base_depth"] = 0 }),
+			&supabase.ResortConditions{BaseDepth: 0, UpdatedAt: time.Now().Add(-time.Hour)}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reasons := ValidateConditions(config, tt.data, tt.previous)
+			assert.Len(t, reasons, tt.want, "%v", reasons)
+		})
+	}
+
+	t.Run("resort limit override", func(t *testing.T) {
+		strict := config
+		strict.Validation.MaxBaseDepthChange = 2
+		reasons := ValidateConditions(strict, row(nil), &supabase.ResortConditions{BaseDepth: 38, UpdatedAt: time.Now()})
+		assert.Equal(t, []string{`base depth rose from 38" to 48" with only 6" of new snow`}, reasons)
+	})
+}
@@ -0,0 +1,219 @@
+package scraping
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"powderhoundgo/config"
+)
+
+// The zones file compiled into the binary, generated with
+// `powderhound avalanche zones`
+const forecastZonesFile = "avalanche-zones.geojson"
+
+// ForecastZone is one avalanche center's forecast zone. Every mountain inside
+// it gets the same forecast
+type ForecastZone struct {
+	// e.g. "CAIC:4f3b1a12..." or "NWAC:1653", unique across centers
+	ID       string
+	CenterID string
+	Name     string
+	geometry geoJSONGeometry
+}
+
+// ForecastZones is the list of zones stored in the zones GeoJSON file
+type ForecastZones []ForecastZone
+
+func forecastZoneID(centerID, id string) string {
+	return centerID + ":" + id
+}
+
+// ParseForecastZones reads a zones FeatureCollection, with each feature's
+// zone_id, center_id and name in its properties
+func ParseForecastZones(data []byte) (ForecastZones, error) {
+	var collection geoJSONFeatureCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse forecast zones: %w", err)
+	}
+	var zones ForecastZones
+	for i, feature := range collection.Features {
+		zone := ForecastZone{geometry: feature.Geometry}
+		zone.ID, _ = feature.Properties["zone_id"].(string)
+		zone.CenterID, _ = feature.Properties["center_id"].(string)
+		zone.Name, _ = feature.Properties["name"].(string)
+		if zone.ID == "" {
+			return nil, fmt.Errorf("forecast zone %d has no zone_id", i)
+		}
+		zones = append(zones, zone)
+	}
+	return zones, nil
+}
+
+// LoadForecastZones reads the zones from AVALANCHE_ZONES_FILE when set, or
+// the zones file embedded in the binary otherwise. A file without zones is an
+// error, since every mountain would otherwise be scraped on its own
+func LoadForecastZones() (ForecastZones, error) {
+	var data []byte
+	var err error
+	if path := os.Getenv("AVALANCHE_ZONES_FILE"); path != "" {
+		data, err = os.ReadFile(path)
+	} else {
+		data, err = fs.ReadFile(config.Files, forecastZonesFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read forecast zones: %w", err)
+	}
+	zones, err := ParseForecastZones(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("the forecast zones file has no zones, generate it with `powderhound avalanche zones`")
+	}
+	return zones, nil
+}
+
+// ZoneAt returns the zone the mountain falls in. A mountain with an
+// avalanche center set only matches that center's zones
+func (z ForecastZones) ZoneAt(mountain MountainCoordinates) (ForecastZone, bool) {
+	for _, zone := range z {
+		if mountain.AvalancheCenter != "" && !strings.EqualFold(mountain.AvalancheCenter, zone.CenterID) {
+			continue
+		}
+		inside, err := zone.geometry.contains(mountain.Lat, mountain.Lon)
+		if err != nil {
+			log.Printf("Warning: skipping forecast zone %s: %v", zone.ID, err)
+			continue
+		}
+		if inside {
+			return zone, true
+		}
+	}
+	return ForecastZone{}, false
+}
+
+// ZoneGroup is the mountains scraped together. The first mountain, the one
+// with the lowest ID, is the one the forecast is fetched for
+type ZoneGroup struct {
+	// Empty for a mountain outside every zone, which is scraped on its own
+	ZoneID    string
+	Mountains []MountainCoordinates
+}
+
+// Group puts the mountains in the same zone together, in order of their
+// first mountain's ID
+func (z ForecastZones) Group(mountains []MountainCoordinates) []ZoneGroup {
+	sorted := append([]MountainCoordinates(nil), mountains...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MountainID < sorted[j].MountainID
+	})
+
+	var groups []ZoneGroup
+	byZone := map[string]int{}
+	for _, mountain := range sorted {
+		zone, ok := z.ZoneAt(mountain)
+		if !ok {
+			groups = append(groups, ZoneGroup{Mountains: []MountainCoordinates{mountain}})
+			continue
+		}
+		if i, ok := byZone[zone.ID]; ok {
+			groups[i].Mountains = append(groups[i].Mountains, mountain)
+			continue
+		}
+		byZone[zone.ID] = len(groups)
+		groups = append(groups, ZoneGroup{ZoneID: zone.ID, Mountains: []MountainCoordinates{mountain}})
+	}
+	return groups
+}
+
+// GeoJSON encodes the zones in the format ParseForecastZones reads, a zone
+// per line so regenerating the file gives a readable diff
+func (z ForecastZones) GeoJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(`{"type": "FeatureCollection", "features": [`)
+	for i, zone := range z {
+		feature, err := json.Marshal(geoJSONFeature{
+			Type: "Feature",
+			Properties: map[string]interface{}{
+				"zone_id":   zone.ID,
+				"center_id": zone.CenterID,
+				"name":      zone.Name,
+			},
+			Geometry: zone.geometry,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode forecast zone %s: %w", zone.ID, err)
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  ")
+		b.Write(feature)
+	}
+	b.WriteString("\n]}\n")
+	return b.Bytes(), nil
+}
+
+// FetchForecastZones downloads the current zones: CAIC's forecast areas,
+// which are finer than its zones on avalanche.org, and every other center's
+// zones from the avalanche.org map layer
+func FetchForecastZones(caic *CAICClient, avalancheOrg *AvalancheOrgClient, now time.Time) (ForecastZones, error) {
+	zones, err := caic.Zones(now)
+	if err != nil {
+		return nil, err
+	}
+	others, err := avalancheOrg.Zones()
+	if err != nil {
+		return nil, err
+	}
+	for _, zone := range others {
+		if zone.CenterID != caicCenterID {
+			zones = append(zones, zone)
+		}
+	}
+	return zones, nil
+}
+
+// Zones returns the forecast areas in effect at now
+func (c *CAICClient) Zones(now time.Time) (ForecastZones, error) {
+	areas, err := c.areas(now)
+	if err != nil {
+		return nil, err
+	}
+	var zones ForecastZones
+	for _, feature := range areas.Features {
+		name, _ := feature.Properties["name"].(string)
+		zones = append(zones, ForecastZone{
+			ID:       forecastZoneID(caicCenterID, feature.featureID()),
+			CenterID: caicCenterID,
+			Name:     name,
+			geometry: feature.Geometry,
+		})
+	}
+	return zones, nil
+}
+
+// Zones returns every center's zones from the map layer
+func (c *AvalancheOrgClient) Zones() (ForecastZones, error) {
+	var layer avalancheOrgMapLayer
+	if err := getJSON(c.HTTPClient, c.BaseURL+"/products/map-layer", &layer); err != nil {
+		return nil, err
+	}
+	var zones ForecastZones
+	for _, zone := range layer.Features {
+		zones = append(zones, ForecastZone{
+			ID:       forecastZoneID(zone.Properties.CenterID, fmt.Sprint(zone.ID)),
+			CenterID: zone.Properties.CenterID,
+			Name:     zone.Properties.Name,
+			geometry: zone.Geometry,
+		})
+	}
+	return zones, nil
+}
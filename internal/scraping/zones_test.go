@@ -0,0 +1,163 @@
+package scraping
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"powderhoundgo/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchForecastZones(t *testing.T) {
+	caicServer, _ := newCAICServer(t)
+	orgServer := newAvalancheOrgServer(t)
+	caic := &CAICClient{BaseURL: caicServer.URL, HTTPClient: caicServer.Client()}
+	avalancheOrg := &AvalancheOrgClient{BaseURL: orgServer.URL, HTTPClient: orgServer.Client()}
+
+	zones, err := FetchForecastZones(caic, avalancheOrg, time.Date(2024, 1, 11, 6, 0, 0, 0, time.UTC))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var ids []string
+	for _, zone := range zones {
+		ids = append(ids, zone.ID)
+	}
+	// CAIC's single zone on avalanche.org is replaced by its own areas
+	assert.Equal(t, []string{
+		"CAIC:4f3b1a12c9e1d0a2f7c4e8b95d6a3e21",
+		"CAIC:9c2e7d41b8a3f6e05d1c4a7b2e9f8d30",
+		"CAIC:e1a8c3f5d7b9024e6a1c3b5d7f9e0a2c",
+		"UAC:1645",
+		"GNFAC:1130",
+		"NWAC:1653",
+	}, ids)
+	assert.Equal(t, "Vail & Summit County", zones[0].Name)
+
+	t.Run("written zones read back the same", func(t *testing.T) {
+		data, err := zones.GeoJSON()
+		if !assert.NoError(t, err) {
+			return
+		}
+		parsed, err := ParseForecastZones(data)
+		if !assert.NoError(t, err) || !assert.Len(t, parsed, len(zones)) {
+			return
+		}
+		for i, zone := range parsed {
+			assert.Equal(t, zones[i].ID, zone.ID)
+			assert.Equal(t, zones[i].CenterID, zone.CenterID)
+			assert.Equal(t, zones[i].Name, zone.Name)
+		}
+		zone, ok := parsed.ZoneAt(MountainCoordinates{Lat: 40.5829, Lon: -111.6556})
+		assert.True(t, ok)
+		assert.Equal(t, "UAC:1645", zone.ID)
+	})
+}
+
+func TestForecastZonesGroup(t *testing.T) {
+	server, _ := newCAICServer(t)
+	caic, err := (&CAICClient{BaseURL: server.URL, HTTPClient: server.Client()}).Zones(time.Date(2024, 1, 11, 6, 0, 0, 0, time.UTC))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	vail := MountainCoordinates{MountainID: 13, Lat: 39.6403, Lon: -106.3742}
+	copper := MountainCoordinates{MountainID: 6, Lat: 39.5022, Lon: -106.1497}
+	aspen := MountainCoordinates{MountainID: 2, Lat: 39.1911, Lon: -106.8175}
+	// Inside the hole in the Aspen area
+	hole := MountainCoordinates{MountainID: 3, Lat: 39.15, Lon: -106.9}
+	// In the Vail area but forecast by another center
+	elsewhere := MountainCoordinates{MountainID: 20, Lat: 39.6, Lon: -106.0, AvalancheCenter: "NWAC"}
+
+	groups := caic.Group([]MountainCoordinates{vail, aspen, elsewhere, copper, hole})
+	assert.Equal(t, []ZoneGroup{
+		{ZoneID: "CAIC:e1a8c3f5d7b9024e6a1c3b5d7f9e0a2c", Mountains: []MountainCoordinates{aspen}},
+		{Mountains: []MountainCoordinates{hole}},
+		{ZoneID: "CAIC:4f3b1a12c9e1d0a2f7c4e8b95d6a3e21", Mountains: []MountainCoordinates{copper, vail}},
+		{Mountains: []MountainCoordinates{elsewhere}},
+	}, groups)
+
+	t.Run("without zones every mountain is on its own", func(t *testing.T) {
+		var zones ForecastZones
+		assert.Len(t, zones.Group([]MountainCoordinates{vail, copper}), 2)
+	})
+}
+
+func TestParseForecastZones(t *testing.T) {
+	t.Run("embedded zones file", func(t *testing.T) {
+		data, err := fs.ReadFile(config.Files, forecastZonesFile)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = ParseForecastZones(data)
+		assert.NoError(t, err)
+	})
+
+	t.Run("empty zones file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "zones.geojson")
+		if !assert.NoError(t, os.WriteFile(path, []byte(`{"type": "FeatureCollection", "features": []}`), 0o644)) {
+			return
+		}
+		t.Setenv("AVALANCHE_ZONES_FILE", path)
+
+		_, err := LoadForecastZones()
+		assert.ErrorContains(t, err, "has no zones")
+	})
+
+	t.Run("missing zones file", func(t *testing.T) {
+		t.Setenv("AVALANCHE_ZONES_FILE", filepath.Join(t.TempDir(), "missing.geojson"))
+
+		_, err := LoadForecastZones()
+		assert.ErrorContains(t, err, "failed to read forecast zones")
+	})
+
+	t.Run("downloaded zones group the development mountains", func(t *testing.T) {
+		caicServer, _ := newCAICServer(t)
+		orgServer := newAvalancheOrgServer(t)
+		caic := &CAICClient{BaseURL: caicServer.URL, HTTPClient: caicServer.Client()}
+		avalancheOrg := &AvalancheOrgClient{BaseURL: orgServer.URL, HTTPClient: orgServer.Client()}
+		zones, err := FetchForecastZones(caic, avalancheOrg, time.Date(2024, 1, 11, 6, 0, 0, 0, time.UTC))
+		if !assert.NoError(t, err) || !assert.NotEmpty(t, zones) {
+			return
+		}
+
+		// The development mock's backcountry mountains
+		loveland := MountainCoordinates{MountainID: 1, Lat: 39.6403, Lon: -105.8719}
+		breckenridge := MountainCoordinates{MountainID: 2, Lat: 39.4817, Lon: -106.0384}
+		snoqualmie := MountainCoordinates{MountainID: 3, Lat: 47.4446, Lon: -121.4271, AvalancheCenter: "NWAC"}
+		assert.Equal(t, []ZoneGroup{
+			{ZoneID: "CAIC:4f3b1a12c9e1d0a2f7c4e8b95d6a3e21", Mountains: []MountainCoordinates{loveland, breckenridge}},
+			{ZoneID: "NWAC:1653", Mountains: []MountainCoordinates{snoqualmie}},
+		}, zones.Group([]MountainCoordinates{loveland, breckenridge, snoqualmie}))
+	})
+
+	t.Run("zone without an id", func(t *testing.T) {
+		_, err := ParseForecastZones([]byte(`{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {"name": "Salt Lake"}, "geometry": {"type": "Polygon", "coordinates": []}}]}`))
+		assert.EqualError(t, err, "forecast zone 0 has no zone_id")
+	})
+}
+
+func TestAvalancheForecastForMountain(t *testing.T) {
+	copper := MountainCoordinates{MountainID: 6, Lat: 39.5022, Lon: -106.1497}
+
+	t.Run("CAIC links are rebuilt for the mountain", func(t *testing.T) {
+		forecast := &AvalancheForecast{MountainID: 13, ForecastURL: "https://avalanche.state.co.us/?lat=39.640300&lng=-106.374200"}
+		zoneForecast := forecast.ForMountain(copper, "CAIC:4f3b1a12c9e1d0a2f7c4e8b95d6a3e21")
+		assert.Equal(t, 6, zoneForecast.MountainID)
+		assert.Equal(t, "CAIC:4f3b1a12c9e1d0a2f7c4e8b95d6a3e21", zoneForecast.ZoneID)
+		assert.Equal(t, "https://avalanche.state.co.us/?lat=39.502200&lng=-106.149700", zoneForecast.ForecastURL)
+		// The scraped forecast is left alone
+		assert.Equal(t, 13, forecast.MountainID)
+		assert.Equal(t, "https://avalanche.state.co.us/?lat=39.640300&lng=-106.374200", forecast.ForecastURL)
+	})
+
+	t.Run("zone links are kept", func(t *testing.T) {
+		forecast := &AvalancheForecast{MountainID: 3, ForecastURL: "https://nwac.us/avalanche-forecast/#/snoqualmie-pass"}
+		zoneForecast := forecast.ForMountain(MountainCoordinates{MountainID: 4, Lat: 47.42, Lon: -121.41}, "NWAC:1653")
+		assert.Equal(t, "https://nwac.us/avalanche-forecast/#/snoqualmie-pass", zoneForecast.ForecastURL)
+	})
+}
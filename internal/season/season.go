@@ -0,0 +1,185 @@
+package season
+
+import (
+	"fmt"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+)
+
+const (
+	// Default for SeasonConfig.PreSeasonDays
+	defaultPreSeasonDays = 30
+	// Hour of the resort's day from which its once a day scrape is queued
+	DailyScrapeHour = 7
+)
+
+// Mode is how often a resort is scraped on a given day
+type Mode string
+
+const (
+	// Scraped on the regular schedule
+	ModeOpen Mode = "open"
+	// Scraped once a day, to catch an early opening
+	ModePreSeason Mode = "pre-season"
+	// Not scraped
+	ModeClosed Mode = "closed"
+)
+
+// Status is a resort's scraping mode on a given day and why
+type Status struct {
+	Mode   Mode
+	Reason string
+}
+
+// A period of days, both inclusive, as midnights in the calendar's timezone
+type dateRange struct {
+	start time.Time
+	end   time.Time
+}
+
+func (r dateRange) contains(day time.Time) bool {
+	return !day.Before(r.start) && !day.After(r.end)
+}
+
+// Calendar answers whether a resort is open on a given day
+type Calendar struct {
+	location      *time.Location
+	opening       *time.Time
+	closing       *time.Time
+	preSeasonDays int
+	closures      []dateRange
+	extensions    []dateRange
+	weekendsOnly  []dateRange
+}
+
+// Parse builds a resort's calendar from its config. The timezone is required
+// whenever a date is set
+func Parse(config supabase.SeasonConfig) (*Calendar, error) {
+	calendar := &Calendar{location: time.UTC, preSeasonDays: config.PreSeasonDays}
+	if calendar.preSeasonDays == 0 {
+		calendar.preSeasonDays = defaultPreSeasonDays
+	}
+	if calendar.preSeasonDays < 0 {
+		return nil, fmt.Errorf("preSeasonDays must not be negative")
+	}
+
+	hasDates := config.Opening != "" || config.Closing != "" || len(config.Closures) > 0 || len(config.Extensions) > 0 || len(config.WeekendsOnly) > 0
+	switch {
+	case config.Timezone != "":
+		location, err := time.LoadLocation(config.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("timezone %q is not an IANA timezone", config.Timezone)
+		}
+		calendar.location = location
+	case hasDates:
+		return nil, fmt.Errorf("timezone is required when dates are set")
+	}
+
+	var err error
+	if calendar.opening, err = calendar.parseDate("opening", config.Opening); err != nil {
+		return nil, err
+	}
+	if calendar.closing, err = calendar.parseDate("closing", config.Closing); err != nil {
+		return nil, err
+	}
+	if calendar.opening != nil && calendar.closing != nil && calendar.closing.Before(*calendar.opening) {
+		return nil, fmt.Errorf("closing %s is before opening %s", config.Closing, config.Opening)
+	}
+
+	if calendar.closures, err = calendar.parseRanges("closures", config.Closures); err != nil {
+		return nil, err
+	}
+	if calendar.extensions, err = calendar.parseRanges("extensions", config.Extensions); err != nil {
+		return nil, err
+	}
+	if calendar.weekendsOnly, err = calendar.parseRanges("weekendsOnly", config.WeekendsOnly); err != nil {
+		return nil, err
+	}
+	return calendar, nil
+}
+
+func (c *Calendar) parseDate(field, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.ParseInLocation(supabase.SeasonDateLayout, value, c.location)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q does not match the layout %q", field, value, supabase.SeasonDateLayout)
+	}
+	return &date, nil
+}
+
+func (c *Calendar) parseRanges(field string, ranges []supabase.DateRange) ([]dateRange, error) {
+	var parsed []dateRange
+	for i, r := range ranges {
+		name := fmt.Sprintf("%s[%d]", field, i)
+		start, err := c.parseDate(name+".start", r.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := c.parseDate(name+".end", r.End)
+		if err != nil {
+			return nil, err
+		}
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("%s needs both a start and an end", name)
+		}
+		if end.Before(*start) {
+			return nil, fmt.Errorf("%s ends before it starts", name)
+		}
+		parsed = append(parsed, dateRange{*start, *end})
+	}
+	return parsed, nil
+}
+
+// StatusAt returns the resort's scraping mode on the day t falls on in the
+// resort's timezone. Closures take priority over extensions, which take
+// priority over weekend-only periods and the season dates
+func (c *Calendar) StatusAt(t time.Time) Status {
+	local := t.In(c.location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.location)
+
+	if inAny(c.closures, day) {
+		return Status{ModeClosed, "mid-season closure"}
+	}
+	if inAny(c.extensions, day) {
+		return Status{ModeOpen, "season extension"}
+	}
+	if inAny(c.weekendsOnly, day) {
+		if weekday := day.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+			return Status{ModeOpen, "weekend-only period"}
+		}
+		return Status{ModeClosed, "weekday in a weekend-only period"}
+	}
+
+	if c.opening != nil && day.Before(*c.opening) {
+		if !day.Before(c.opening.AddDate(0, 0, -c.preSeasonDays)) {
+			return Status{ModePreSeason, fmt.Sprintf("opens %s", c.opening.Format(supabase.SeasonDateLayout))}
+		}
+		return Status{ModeClosed, fmt.Sprintf("season opens %s", c.opening.Format(supabase.SeasonDateLayout))}
+	}
+	if c.closing != nil && day.After(*c.closing) {
+		return Status{ModeClosed, fmt.Sprintf("season closed %s", c.closing.Format(supabase.SeasonDateLayout))}
+	}
+	return Status{ModeOpen, "in season"}
+}
+
+func inAny(ranges []dateRange, day time.Time) bool {
+	for _, r := range ranges {
+		if r.contains(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// DailyScrapeKey identifies the once a day scrape a pre-season resort is due
+// at t, e.g. "2024-11-02", or "" before DailyScrapeHour in the resort's timezone
+func (c *Calendar) DailyScrapeKey(t time.Time) string {
+	local := t.In(c.location)
+	if local.Hour() < DailyScrapeHour {
+		return ""
+	}
+	return local.Format(supabase.SeasonDateLayout)
+}
@@ -0,0 +1,103 @@
+package season
+
+import (
+	"testing"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func denverCalendar(t *testing.T) *Calendar {
+	calendar, err := Parse(supabase.SeasonConfig{
+		Timezone:      "America/Denver",
+		Opening:       "2023-11-10",
+		Closing:       "2024-04-21",
+		PreSeasonDays: 14,
+		Closures:      []supabase.DateRange{{Start: "2024-01-08", End: "2024-01-09"}},
+		Extensions:    []supabase.DateRange{{Start: "2024-04-22", End: "2024-04-28"}},
+		WeekendsOnly:  []supabase.DateRange{{Start: "2024-05-01", End: "2024-05-31"}},
+	})
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	return calendar
+}
+
+func TestStatusAt(t *testing.T) {
+	calendar := denverCalendar(t)
+	denver := calendar.location
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected Mode
+	}{
+		{"long before opening", time.Date(2023, 9, 1, 12, 0, 0, 0, denver), ModeClosed},
+		{"first pre-season day", time.Date(2023, 10, 27, 0, 0, 0, 0, denver), ModePreSeason},
+		{"day before opening", time.Date(2023, 11, 9, 23, 0, 0, 0, denver), ModePreSeason},
+		{"opening day", time.Date(2023, 11, 10, 0, 0, 0, 0, denver), ModeOpen},
+		{"mid-season closure", time.Date(2024, 1, 9, 12, 0, 0, 0, denver), ModeClosed},
+		{"day after the closure", time.Date(2024, 1, 10, 12, 0, 0, 0, denver), ModeOpen},
+		{"closing day", time.Date(2024, 4, 21, 17, 0, 0, 0, denver), ModeOpen},
+		{"extension", time.Date(2024, 4, 25, 12, 0, 0, 0, denver), ModeOpen},
+		{"between the extension and weekends", time.Date(2024, 4, 30, 12, 0, 0, 0, denver), ModeClosed},
+		{"weekend-only Saturday", time.Date(2024, 5, 4, 12, 0, 0, 0, denver), ModeOpen},
+		{"weekend-only Wednesday", time.Date(2024, 5, 8, 12, 0, 0, 0, denver), ModeClosed},
+		{"after the season", time.Date(2024, 6, 15, 12, 0, 0, 0, denver), ModeClosed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, calendar.StatusAt(test.at).Mode)
+		})
+	}
+}
+
+func TestStatusAtUsesResortTimezone(t *testing.T) {
+	calendar := denverCalendar(t)
+
+	// 02:00 UTC on the 22nd is still closing day in Denver
+	assert.Equal(t, ModeOpen, calendar.StatusAt(time.Date(2024, 4, 22, 2, 0, 0, 0, time.UTC)).Mode)
+	// 02:00 UTC on the 10th of November is still pre-season in Denver
+	assert.Equal(t, ModePreSeason, calendar.StatusAt(time.Date(2023, 11, 10, 2, 0, 0, 0, time.UTC)).Mode)
+}
+
+func TestStatusAtWithoutDates(t *testing.T) {
+	calendar, err := Parse(supabase.SeasonConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, Status{ModeOpen, "in season"}, calendar.StatusAt(time.Now()))
+}
+
+func TestDailyScrapeKey(t *testing.T) {
+	calendar := denverCalendar(t)
+	denver := calendar.location
+
+	assert.Equal(t, "", calendar.DailyScrapeKey(time.Date(2023, 11, 1, 6, 59, 0, 0, denver)))
+	assert.Equal(t, "2023-11-01", calendar.DailyScrapeKey(time.Date(2023, 11, 1, 7, 0, 0, 0, denver)))
+	assert.Equal(t, "2023-11-01", calendar.DailyScrapeKey(time.Date(2023, 11, 1, 23, 0, 0, 0, denver)))
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   supabase.SeasonConfig
+		expected string
+	}{
+		{"dates without a timezone", supabase.SeasonConfig{Closing: "2024-04-21"}, "timezone is required when dates are set"},
+		{"unknown timezone", supabase.SeasonConfig{Timezone: "Mountain"}, `timezone "Mountain" is not an IANA timezone`},
+		{"old closing date layout", supabase.SeasonConfig{Timezone: "UTC", Closing: "2024-04-21 5:00pm (MST)"}, `closing "2024-04-21 5:00pm (MST)" does not match the layout "2006-01-02"`},
+		{"closing before opening", supabase.SeasonConfig{Timezone: "UTC", Opening: "2024-11-10", Closing: "2024-04-21"}, "closing 2024-04-21 is before opening 2024-11-10"},
+		{"range without an end", supabase.SeasonConfig{Timezone: "UTC", Closures: []supabase.DateRange{{Start: "2024-01-08"}}}, "closures[0] needs both a start and an end"},
+		{"backwards range", supabase.SeasonConfig{Timezone: "UTC", Extensions: []supabase.DateRange{{Start: "2024-05-08", End: "2024-05-01"}}}, "extensions[0] ends before it starts"},
+		{"negative pre-season", supabase.SeasonConfig{PreSeasonDays: -1}, "preSeasonDays must not be negative"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.config)
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package storm
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+)
+
+const (
+	// A storm ends once no new snow has been reported for this long, so a
+	// lull of a few hours doesn't split it in two
+	QuietPeriod = 24 * time.Hour
+	// The previous conditions row is only compared against when it's recent
+	// enough to share a 48 hour window with the new one
+	comparisonWindow = 48 * time.Hour
+	// Inches a reset's 48 hour total can be off from the previous 24 hour
+	// total plus the new one, for rounding and snow that fell in between
+	resetTolerance = 1
+)
+
+// NewSnow returns how many inches fell between the resort's previous
+// conditions row and a freshly scraped one. Resorts reset their 24 hour
+// total with each morning report, and the 48 hour total then holds the
+// previous report's 24 hours plus the new one's, which is used to recover the
+// snow that fell across the reset. Any other drop is a bad read or a rolling
+// 24 hour total losing old snow, and adds nothing. previous is nil before a
+// resort's first upsert
+func NewSnow(previous *supabase.ResortConditions, snow24, snow48 float64, now time.Time) float64 {
+	if previous == nil || now.Sub(previous.UpdatedAt) > comparisonWindow {
+		return max(snow24, snow48, 0)
+	}
+
+	if snow24 >= previous.SnowPast24h {
+		newSnow := snow24 - previous.SnowPast24h
+		// Snow that fell since the previous reading is in the 48 hour total
+		// too. When that didn't rise with it, the previous 24 hour reading was
+		// a bad read and the rise is only the report recovering from it
+		if snow48 >= snow24 && previous.SnowPast48h > 0 {
+			newSnow = min(newSnow, max(snow48-previous.SnowPast48h, 0))
+		}
+		return newSnow
+	}
+	// Without a 48 hour total a reset can't be told apart from a bad read
+	if snow48 < snow24 {
+		return max(snow24, 0)
+	}
+	// The report reset. Anything above the last 24 hour reading in the new
+	// 48 hour total fell after that reading
+	if math.Abs(snow48-(previous.SnowPast24h+snow24)) <= resetTolerance {
+		return max(snow48-previous.SnowPast24h, 0)
+	}
+	return 0
+}
+
+// Advance applies newly reported snow to a resort's active storm. It returns
+// the storm to save, which is a new storm when snow starts falling with none
+// active, or nil when nothing changed. active is nil when it isn't snowing
+func Advance(active *supabase.Storm, config supabase.ScrapingConfig, newSnow float64, now time.Time) *supabase.Storm {
+	switch {
+	case active == nil && newSnow > 0:
+		return &supabase.Storm{
+			MountainID:   config.ID,
+			MountainName: config.Name,
+			StartedAt:    now,
+			LastSnowAt:   now,
+			Total:        newSnow,
+		}
+	case active == nil:
+		return nil
+	case newSnow > 0:
+		storm := *active
+		storm.Total += newSnow
+		storm.LastSnowAt = now
+		return &storm
+	case now.Sub(active.LastSnowAt) >= QuietPeriod:
+		storm := *active
+		endedAt := active.LastSnowAt
+		storm.EndedAt = &endedAt
+		return &storm
+	}
+	return nil
+}
+
+// Track updates the resort's storm record with a scrape that is about to
+// replace previous as its current conditions
+func Track(client supabase.SupabaseClient, config supabase.ScrapingConfig, previous *supabase.ResortConditions, data map[string]interface{}, now time.Time) error {
+	snow24, _ := data["snow_past_24h"].(float64)
+	snow48, _ := data["snow_past_48h"].(float64)
+	newSnow := NewSnow(previous, snow24, snow48, now)
+
+	active, err := client.GetActiveStorm(config.ID)
+	if err != nil {
+		return err
+	}
+
+	storm := Advance(active, config, newSnow, now)
+	switch {
+	case storm == nil:
+		return nil
+	case active == nil:
+		log.Printf("Storm started at %s with %g\"", config.Name, storm.Total)
+		return client.InsertStorm(*storm)
+	case storm.EndedAt != nil:
+		log.Printf("Storm ended at %s with %g\"", config.Name, storm.Total)
+	default:
+		log.Printf("Storm at %s has reached %g\"", config.Name, storm.Total)
+	}
+	return client.UpdateStorm(*storm)
+}
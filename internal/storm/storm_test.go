@@ -0,0 +1,145 @@
+package storm
+
+import (
+	"testing"
+	"time"
+
+	"powderhoundgo/internal/supabase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// In-memory storms table. Methods the storm package doesn't use panic through
+// the nil embedded client
+type fakeClient struct {
+	supabase.SupabaseClient
+	storms []supabase.Storm
+}
+
+func (f *fakeClient) GetActiveStorm(mountainID int) (*supabase.Storm, error) {
+	for i := range f.storms {
+		if f.storms[i].MountainID == mountainID && f.storms[i].EndedAt == nil {
+			storm := f.storms[i]
+			return &storm, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeClient) InsertStorm(storm supabase.Storm) error {
+	storm.ID = len(f.storms) + 1
+	f.storms = append(f.storms, storm)
+	return nil
+}
+
+func (f *fakeClient) UpdateStorm(storm supabase.Storm) error {
+	f.storms[storm.ID-1] = storm
+	return nil
+}
+
+var (
+	start  = time.Date(2024, 1, 10, 6, 0, 0, 0, time.UTC)
+	config = supabase.ScrapingConfig{ID: 7, Name: "Breckenridge"}
+)
+
+func TestNewSnow(t *testing.T) {
+	previous := &supabase.ResortConditions{SnowPast24h: 4, SnowPast48h: 6, UpdatedAt: start}
+	hourLater := start.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		previous *supabase.ResortConditions
+		snow24   float64
+		snow48   float64
+		now      time.Time
+		expected float64
+	}{
+		{"same report, more snow", previous, 7, 9, hourLater, 3},
+		{"same report, no change", previous, 4, 6, hourLater, 0},
+		{"report reset with new snow", previous, 2, 6, hourLater, 2},
+		{"report reset picks up snow after the last reading", previous, 2, 7, hourLater, 3},
+		{"report reset to nothing", previous, 0, 4, hourLater, 0},
+		{"48 hour total missing after reset", previous, 2, 0, hourLater, 2},
+		{"first scrape", nil, 3, 5, start, 5},
+		{"stale previous row", previous, 3, 3, start.Add(72 * time.Hour), 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, NewSnow(test.previous, test.snow24, test.snow48, test.now))
+		})
+	}
+}
+
+func TestNewSnowIgnoresDropsThatArentResets(t *testing.T) {
+	t.Run("one-off bad read", func(t *testing.T) {
+		previous := &supabase.ResortConditions{SnowPast24h: 6, SnowPast48h: 14, UpdatedAt: start}
+		assert.Equal(t, 0.0, NewSnow(previous, 0, 14, start.Add(time.Hour)))
+
+		// The bad read was saved, the next correct one recovers from it
+		bad := &supabase.ResortConditions{SnowPast24h: 0, SnowPast48h: 14, UpdatedAt: start.Add(time.Hour)}
+		assert.Equal(t, 0.0, NewSnow(bad, 6, 14, start.Add(2*time.Hour)))
+		assert.Equal(t, 1.0, NewSnow(bad, 7, 15, start.Add(2*time.Hour)))
+	})
+
+	t.Run("rolling 24 hour total", func(t *testing.T) {
+		// 8" over the last day, and hours later the first 3" are more than
+		// 24 hours old
+		previous := &supabase.ResortConditions{SnowPast24h: 8, SnowPast48h: 8, UpdatedAt: start}
+		assert.Equal(t, 0.0, NewSnow(previous, 5, 8, start.Add(4*time.Hour)))
+	})
+}
+
+func TestAdvance(t *testing.T) {
+	assert.Nil(t, Advance(nil, config, 0, start))
+
+	storm := Advance(nil, config, 3, start)
+	assert.Equal(t, supabase.Storm{MountainID: 7, MountainName: "Breckenridge", StartedAt: start, LastSnowAt: start, Total: 3}, *storm)
+
+	later := start.Add(5 * time.Hour)
+	grown := Advance(storm, config, 2, later)
+	assert.Equal(t, 5.0, grown.Total)
+	assert.Equal(t, later, grown.LastSnowAt)
+	assert.Equal(t, 3.0, storm.Total, "the active storm isn't modified")
+
+	assert.Nil(t, Advance(grown, config, 0, later.Add(QuietPeriod-time.Minute)))
+
+	ended := Advance(grown, config, 0, later.Add(QuietPeriod))
+	assert.Equal(t, later, *ended.EndedAt)
+	assert.Equal(t, 5.0, ended.Total)
+}
+
+func TestTrackStormAcrossReportResets(t *testing.T) {
+	client := &fakeClient{}
+	var previous *supabase.ResortConditions
+
+	scrape := func(snow24, snow48 float64, at time.Time) {
+		data := map[string]interface{}{"snow_past_24h": snow24, "snow_past_48h": snow48}
+		assert.NoError(t, Track(client, config, previous, data, at))
+		previous = &supabase.ResortConditions{SnowPast24h: snow24, SnowPast48h: snow48, UpdatedAt: at}
+	}
+
+	// Quiet start, then 8" over the first day, a morning reset and 6" more
+	scrape(0, 0, start)
+	assert.Empty(t, client.storms)
+	scrape(3, 3, start.Add(4*time.Hour))
+	scrape(8, 8, start.Add(12*time.Hour))
+	scrape(4, 12, start.Add(24*time.Hour))
+	scrape(6, 14, start.Add(30*time.Hour))
+	// Next morning's report resets to nothing and it stays dry
+	scrape(0, 6, start.Add(48*time.Hour))
+	scrape(0, 6, start.Add(53*time.Hour))
+
+	assert.Len(t, client.storms, 1)
+	assert.Equal(t, 14.0, client.storms[0].Total)
+	assert.Nil(t, client.storms[0].EndedAt)
+
+	scrape(0, 0, start.Add(54*time.Hour))
+	assert.Equal(t, start.Add(30*time.Hour), *client.storms[0].EndedAt)
+
+	// The next snowfall is a new storm
+	scrape(2, 2, start.Add(80*time.Hour))
+	assert.Len(t, client.storms, 2)
+	assert.Equal(t, 2.0, client.storms[1].Total)
+	assert.Equal(t, 14.0, client.storms[0].Total)
+}
@@ -1,13 +1,18 @@
 package supabase
 
 import (
-	storage_go "github.com/supabase-community/storage-go"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/supabase-community/supabase-go"
 )
 
 type OvernightAlert struct {
-	Location string `json:"display_name"`
-	Snowfall int    `json:"snow_past_24h"`
+	MountainID int     `json:"mountain_id"`
+	Location   string  `json:"display_name"`
+	Snowfall   float64 `json:"snow_past_24h"`
 }
 
 type ForecastAlert struct {
@@ -25,21 +30,146 @@ type UserForecastAlert struct {
 	Alerts []ForecastAlert `json:"alerts"`
 }
 
+// A backcountry mountain a user gets avalanche danger alerts for
+type AvalancheSubscription struct {
+	MountainID int    `json:"mountain_id"`
+	Location   string `json:"display_name"`
+}
+
+type UserAvalancheSubscriptions struct {
+	Email     string                  `json:"email"`
+	Mountains []AvalancheSubscription `json:"mountains"`
+}
+
 type ScrapingStatusData struct {
 	MountainName string
 	Success      bool
 	Error        string
+	// Field-level results of a resort scrape, nil for other scraping jobs
+	Diagnostics *ScrapeDiagnostics
+	// Fields that used to resolve but have stopped, see scraping.DetectSelectorDrift
+	DriftingFields []string
+	// Keys of the screenshot, DOM and browser log captured when the scrape failed
+	Artifacts []string
+}
+
+// How a field fared in a single scrape
+const (
+	FieldResolved = "resolved"
+	// No selector matched, or the matched element was empty
+	FieldEmpty = "empty"
+	// Text was found but couldn't be parsed, so the value fell back to 0
+	FieldDefaulted = "defaulted"
+)
+
+// FieldDiagnostic records how one field was read during a scrape
+type FieldDiagnostic struct {
+	Field string `json:"field"`
+	// Selector or JSONPath the text was read from, empty when nothing matched
+	Selector string `json:"selector,omitempty"`
+	RawText  string `json:"raw_text"`
+	// Parsed value: inches for measurements, a count for lifts and runs and
+	// the text itself for the snowpack
+	Value  interface{} `json:"value"`
+	Status string      `json:"status"`
+}
+
+// ScrapeDiagnostics is stored with each scraping_status row so selector
+// drift can be spotted across runs
+type ScrapeDiagnostics struct {
+	Fields []FieldDiagnostic `json:"fields"`
+	// The base depth never showed numeric data while waiting for the page to hydrate
+	HydrationExhausted bool `json:"hydration_exhausted"`
+}
+
+// Status returns the named field's status, or false when the scrape didn't record it
+func (d ScrapeDiagnostics) Status(field string) (string, bool) {
+	for _, diagnostic := range d.Fields {
+		if diagnostic.Field == field {
+			return diagnostic.Status, true
+		}
+	}
+	return "", false
+}
+
+// FieldSelector locates a single value on a resort's page. Selectors are tried
+// in order until one matches, so a fallback can take over when the resort changes
+// its markup. Pattern, if set, is a regular expression whose first capture group
+// is taken from the matched text.
+//
+// In JSON it can be a single selector string, a list of selectors, or an object
+// with "selectors" and "pattern"
+type FieldSelector struct {
+	Selectors []string `json:"selectors"`
+	Pattern   string   `json:"pattern,omitempty"`
+}
+
+func NewFieldSelector(selectors ...string) FieldSelector {
+	var field FieldSelector
+	for _, selector := range selectors {
+		if selector != "" {
+			field.Selectors = append(field.Selectors, selector)
+		}
+	}
+	return field
+}
+
+func (f FieldSelector) IsSet() bool {
+	return len(f.Selectors) > 0
+}
+
+// String joins the selectors into a CSS selector group that matches any of them
+func (f FieldSelector) String() string {
+	return strings.Join(f.Selectors, ", ")
+}
+
+func (f *FieldSelector) UnmarshalJSON(data []byte) error {
+	var selector string
+	if err := json.Unmarshal(data, &selector); err == nil {
+		*f = NewFieldSelector(selector)
+		return nil
+	}
+
+	var selectors []string
+	if err := json.Unmarshal(data, &selectors); err == nil {
+		*f = NewFieldSelector(selectors...)
+		return nil
+	}
+
+	type fieldSelector FieldSelector
+	var field fieldSelector
+	if err := json.Unmarshal(data, &field); err != nil {
+		return fmt.Errorf("selector must be a string, a list of strings or an object: %w", err)
+	}
+	*f = FieldSelector(field)
+	return nil
+}
+
+// Writes the shortest of the accepted JSON forms
+func (f FieldSelector) MarshalJSON() ([]byte, error) {
+	if f.Pattern != "" {
+		type fieldSelector FieldSelector
+		return json.Marshal(fieldSelector(f))
+	}
+	switch len(f.Selectors) {
+	case 0:
+		return json.Marshal("")
+	case 1:
+		return json.Marshal(f.Selectors[0])
+	default:
+		return json.Marshal(f.Selectors)
+	}
 }
 
 type ConditionsConfig struct {
-	ConditionsSelector  string `json:"conditionsSelector"`
-	BaseDepthSelector   string `json:"baseDepthSelector"`
-	SnowpackSelector    string `json:"snowpackSelector"`
-	SeasonTotalSelector string `json:"seasonTotalSelector"`
-	Snow24Selector      string `json:"snow24Selector"`
-	Snow48Selector      string `json:"snow48Selector"`
-	Snow7DaySelector    string `json:"snow7DaySelector"`
-	WaitForSelector     string `json:"waitForSelector"`
+	ConditionsSelector  string        `json:"conditionsSelector"`
+	BaseDepthSelector   FieldSelector `json:"baseDepthSelector"`
+	SnowpackSelector    FieldSelector `json:"snowpackSelector"`
+	SeasonTotalSelector FieldSelector `json:"seasonTotalSelector"`
+	Snow24Selector      FieldSelector `json:"snow24Selector"`
+	Snow48Selector      FieldSelector `json:"snow48Selector"`
+	Snow7DaySelector    FieldSelector `json:"snow7DaySelector"`
+	WaitForSelector     string        `json:"waitForSelector"`
 }
 
 type TerrainConfig struct {
@@ -55,42 +185,270 @@ type TerrainConfig struct {
 	RunClickSelector           string `json:"runClickSelector"`
 }
 
+// Layout of the dates in a SeasonConfig, e.g. "2024-04-21"
+const SeasonDateLayout = "2006-01-02"
+
+// SeasonConfig is a resort's season calendar. Dates are inclusive days in
+// Timezone, and any of them can be left out
+type SeasonConfig struct {
+	// IANA name of the resort's timezone, e.g. "America/Denver"
+	Timezone string `json:"timezone"`
+	Opening  string `json:"opening,omitempty"`
+	Closing  string `json:"closing,omitempty"`
+	// Days before Opening the resort is scraped once a day to catch an early opening
+	PreSeasonDays int `json:"preSeasonDays,omitempty"`
+	// Periods the resort is closed during the season
+	Closures []DateRange `json:"closures,omitempty"`
+	// Periods the resort stays open past Closing
+	Extensions []DateRange `json:"extensions,omitempty"`
+	// Periods the resort is only open on Saturdays and Sundays
+	WeekendsOnly []DateRange `json:"weekendsOnly,omitempty"`
+}
+
+type DateRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Extraction engines available to a scraping config
+const (
+	// Renders the resort's pages in headless Chrome (the default)
+	EngineChromedp = "chromedp"
+	// Fetches the pages with a plain HTTP request and parses the returned HTML.
+	// Only suitable for server-rendered pages that need no click interactions
+	EngineHTTP = "http"
+	// Reads the snow report from a JSON endpoint using the paths in APIConfig
+	EngineJSON = "json"
+)
+
+// Units a resort can report snow measurements in. ScrapingConfig.Unit applies to
+// measurements that don't state their own unit and defaults to inches, which is
+// also the unit measurements are stored in
+const (
+	UnitInches      = "in"
+	UnitFeet        = "ft"
+	UnitCentimeters = "cm"
+)
+
+// JSONPath expressions (e.g. "$.snowReport.baseDepth") locating each value in a resort's JSON feed
+type APIConfig struct {
+	URL         string            `json:"url"`
+	Headers     map[string]string `json:"headers"`
+	BaseDepth   string            `json:"baseDepth"`
+	Snowpack    string            `json:"snowpack"`
+	SeasonTotal string            `json:"seasonTotal"`
+	Snow24      string            `json:"snow24"`
+	Snow48      string            `json:"snow48"`
+	Snow7Day    string            `json:"snow7Day"`
+	LiftsOpen   string            `json:"liftsOpen"`
+	RunsOpen    string            `json:"runsOpen"`
+}
+
+// Interactions a scraping step can perform on the page
+const (
+	StepNavigate    = "navigate"
+	StepClick       = "click"
+	StepClickAll    = "click-all"
+	StepWaitVisible = "wait-visible"
+	StepScroll      = "scroll"
+	StepSelectTab   = "select-tab"
+	StepSleep       = "sleep"
+)
+
+// Extraction stages a step can run before
+const (
+	StageConditions = "conditions"
+	StageTerrain    = "terrain"
+)
+
+// Step is a single page interaction. Steps run in order before the extraction
+// stage named by Before, which defaults to the conditions stage
+type Step struct {
+	Action   string `json:"action"`
+	Before   string `json:"before,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Selector string `json:"selector,omitempty"`
+	// Label of the tab to pick from the elements matching Selector (select-tab)
+	Text string `json:"text,omitempty"`
+	// Go duration string, e.g. "2s" (sleep)
+	Duration string `json:"duration,omitempty"`
+}
+
 type ScrapingConfig struct {
-	ID            int              `json:"id"`
-	Name          string           `json:"name"`
-	ClosingDate   string           `json:"closingDate"`
+	ID     int          `json:"id"`
+	Name   string       `json:"name"`
+	Engine string       `json:"engine"`
+	Unit   string       `json:"unit"`
+	Season SeasonConfig `json:"season"`
+	// Deprecated: configs still in the bucket carry the closing date here, it
+	// is used when season.closing isn't set
+	ClosingDate   string           `json:"closingDate,omitempty"`
 	SeparateURLs  bool             `json:"separateURLs"`
 	ClickSelector string           `json:"clickSelector"`
 	ConditionsURL string           `json:"conditionsURL"`
 	TerrainURL    string           `json:"terrainURL"`
 	Conditions    ConditionsConfig `json:"conditions"`
 	Terrain       TerrainConfig    `json:"terrain"`
+	API           APIConfig        `json:"api"`
+	Steps         []Step           `json:"steps"`
+	Validation    ValidationConfig `json:"validation"`
+}
+
+// Timezones of the abbreviations in legacy closing dates
+var legacyTimezones = map[string]string{
+	"MST": "America/Denver", "MDT": "America/Denver",
+	"PST": "America/Los_Angeles", "PDT": "America/Los_Angeles",
+}
+
+// SeasonCalendar returns the season with the legacy closingDate, e.g.
+// "2024-04-21 5:00pm (MST)", filled in as its closing day when season.closing
+// isn't set. Its zone abbreviation stands in for a missing timezone
+func (c ScrapingConfig) SeasonCalendar() SeasonConfig {
+	season := c.Season
+	if season.Closing != "" || c.ClosingDate == "" {
+		return season
+	}
+
+	season.Closing = c.ClosingDate
+	if len(c.ClosingDate) >= len(SeasonDateLayout) {
+		if _, err := time.Parse(SeasonDateLayout, c.ClosingDate[:len(SeasonDateLayout)]); err == nil {
+			season.Closing = c.ClosingDate[:len(SeasonDateLayout)]
+		}
+	}
+	if season.Timezone == "" {
+		season.Timezone = "UTC"
+		for abbreviation, timezone := range legacyTimezones {
+			if strings.Contains(c.ClosingDate, "("+abbreviation+")") {
+				season.Timezone = timezone
+			}
+		}
+	}
+	return season
+}
+
+// ValidationConfig holds the per-resort limits used to reject implausible
+// scrapes. Zero values fall back to the defaults or skip the check
+type ValidationConfig struct {
+	// Lifts and runs the resort has in total, 0 when unknown
+	TotalLifts int `json:"totalLifts,omitempty"`
+	TotalRuns  int `json:"totalRuns,omitempty"`
+	// Largest change in base depth, in inches, allowed between two scrapes on
+	// top of the new 48 hour snowfall
+	MaxBaseDepthChange int `json:"maxBaseDepthChange,omitempty"`
+}
+
+// ResortConditions is a resort's current resort_conditions row
+type ResortConditions struct {
+	MountainID   int       `json:"mountain_id"`
+	BaseDepth    float64   `json:"base_depth"`
+	SnowPast24h  float64   `json:"snow_past_24h"`
+	SnowPast48h  float64   `json:"snow_past_48h"`
+	SnowPastWeek float64   `json:"snow_past_week"`
+	SnowTotal    float64   `json:"snow_total"`
+	LiftsOpen    int       `json:"lifts_open"`
+	RunsOpen     int       `json:"runs_open"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Resolution of a conditions_history row. Hourly rows are downsampled to one
+// daily row per day once they age out of the hourly retention window
+const (
+	ResolutionHourly = "hourly"
+	ResolutionDaily  = "daily"
+)
+
+// ConditionsSnapshot is a row of conditions_history, a resort's conditions as
+// they were scraped at RecordedAt
+type ConditionsSnapshot struct {
+	ID           int       `json:"id,omitempty"`
+	MountainID   int       `json:"mountain_id"`
+	RecordedAt   time.Time `json:"recorded_at"`
+	Resolution   string    `json:"resolution"`
+	BaseDepth    float64   `json:"base_depth"`
+	SnowPast24h  float64   `json:"snow_past_24h"`
+	SnowPast48h  float64   `json:"snow_past_48h"`
+	SnowPastWeek float64   `json:"snow_past_week"`
+	SnowTotal    float64   `json:"snow_total"`
+	LiftsOpen    int       `json:"lifts_open"`
+	RunsOpen     int       `json:"runs_open"`
+	SnowType     string    `json:"snow_type"`
+}
+
+// Storm is a row of storms, one snowfall event at a resort. EndedAt is nil
+// while the storm is still active
+type Storm struct {
+	ID           int        `json:"id,omitempty"`
+	MountainID   int        `json:"mountain_id"`
+	MountainName string     `json:"display_name"`
+	StartedAt    time.Time  `json:"started_at"`
+	LastSnowAt   time.Time  `json:"last_snow_at"`
+	EndedAt      *time.Time `json:"ended_at"`
+	Total        float64    `json:"total"`
+}
+
+// AvalancheForecastVersion is a row of avalanche_forecast_versions, a
+// forecast as it was issued. Rows are unique per mountain and issue date
+type AvalancheForecastVersion struct {
+	ID                 int             `json:"id,omitempty"`
+	MountainID         int             `json:"mountain_id"`
+	IssueDate          time.Time       `json:"issue_date"`
+	OverallDangerLevel int             `json:"overall_danger_level"`
+	AvalancheSummary   string          `json:"avalanche_summary"`
+	DangerLevels       json.RawMessage `json:"danger_levels"`
+	AvalancheProblems  json.RawMessage `json:"avalanche_problems"`
+	ForecastURL        string          `json:"forecast_url"`
+	ZoneID             string          `json:"zone_id"`
+	RecordedAt         time.Time       `json:"recorded_at"`
+}
+
+// QuarantinedConditions is a scrape that failed validation, kept for review
+// instead of overwriting the resort's current conditions
+type QuarantinedConditions struct {
+	MountainID   int
+	MountainName string
+	Conditions   map[string]interface{}
+	Reasons      []string
 }
 
 // MountainCoordinates represents a mountain's location for avalanche forecasting
 type MountainCoordinates struct {
-	MountainID int     `json:"mountain_id"`
-	Lat        float64 `json:"lat"`
-	Lon        float64 `json:"lon"`
+	MountainID      int     `json:"mountain_id"`
+	Lat             float64 `json:"lat"`
+	Lon             float64 `json:"lon"`
+	AvalancheCenter string  `json:"avalanche_center"`
 }
 
 type SupabaseClient interface {
 	UpsertResortConditionsData(data map[string]interface{}) error
 	GetUserOvernightAlerts() []UserOvernightAlert
 	GetUserForecastAlerts() []UserForecastAlert
+	GetUserAvalancheSubscriptions() ([]UserAvalancheSubscriptions, error)
 	InsertScrapingStatus(data ScrapingStatusData) error
-	GetConfigByName(name string) ScrapingConfig
-	GetAllMountainObjectNames() []string
+	GetRecentScrapeDiagnostics(mountainName string, limit int) ([]ScrapeDiagnostics, error)
+	GetResortConditions(mountainID int) (*ResortConditions, error)
+	InsertQuarantinedConditions(data QuarantinedConditions) error
+	// Conditions history methods
+	InsertConditionsSnapshot(snapshot ConditionsSnapshot) error
+	GetConditionsSnapshotAt(mountainID int, at time.Time) (*ConditionsSnapshot, error)
+	GetConditionsSnapshots(mountainID int, from, to time.Time) ([]ConditionsSnapshot, error)
+	GetHourlyConditionsSnapshots(before time.Time) ([]ConditionsSnapshot, error)
+	MarkConditionsSnapshotsDaily(ids []int) error
+	DeleteConditionsSnapshots(ids []int) error
+	// Storm methods
+	GetActiveStorm(mountainID int) (*Storm, error)
+	GetStorms(mountainID int, since time.Time) ([]Storm, error)
+	InsertStorm(storm Storm) error
+	UpdateStorm(storm Storm) error
 	// Avalanche forecast methods
 	UpsertAvalancheForecast(data map[string]interface{}) error
 	GetMountainsWithAvalancheForecasts() ([]MountainCoordinates, error)
+	GetAvalancheForecastIssueDate(mountainID int) (*time.Time, error)
+	UpsertAvalancheForecastVersion(version AvalancheForecastVersion) error
+	GetAvalancheForecastVersions(mountainID int, since time.Time) ([]AvalancheForecastVersion, error)
 }
 
 type SupabaseService struct {
-	client        *supabase.Client
-	storageClient *storage_go.Client
+	client *supabase.Client
 }
 
-type MockSupabaseService struct {
-	storageClient *storage_go.Client
-}
+type MockSupabaseService struct{}
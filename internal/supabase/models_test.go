@@ -0,0 +1,71 @@
+package supabase
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFieldSelectorJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want FieldSelector
+	}{
+		{"empty string", `""`, FieldSelector{}},
+		{"single selector", `".base > h5"`, FieldSelector{Selectors: []string{".base > h5"}}},
+		{"fallback list", `[".base > h5", "[data-stat='base']"]`, FieldSelector{Selectors: []string{".base > h5", "[data-stat='base']"}}},
+		{"object with pattern", `{"selectors": [".base"], "pattern": "Base:\\s*(\\d+)"}`, FieldSelector{Selectors: []string{".base"}, Pattern: `Base:\s*(\d+)`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got FieldSelector
+			if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+				t.Fatalf("failed to unmarshal %s: %v", tt.json, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+
+			// Marshalling picks the shortest form, which must decode back to the same selector
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("failed to marshal %#v: %v", got, err)
+			}
+			var roundTrip FieldSelector
+			if err := json.Unmarshal(data, &roundTrip); err != nil {
+				t.Fatalf("failed to unmarshal %s: %v", data, err)
+			}
+			if !reflect.DeepEqual(roundTrip, tt.want) {
+				t.Errorf("Expected %#v after round trip through %s, got %#v", tt.want, data, roundTrip)
+			}
+		})
+	}
+
+	var invalid FieldSelector
+	if err := json.Unmarshal([]byte(`42`), &invalid); err == nil {
+		t.Errorf("Expected an error for a numeric selector")
+	}
+}
+
+func TestSeasonCalendarClosingDateFallback(t *testing.T) {
+	var legacy ScrapingConfig
+	if err := json.Unmarshal([]byte(`{"closingDate": "2024-04-21 5:00pm (MST)"}`), &legacy); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	want := SeasonConfig{Timezone: "America/Denver", Closing: "2024-04-21"}
+	if got := legacy.SeasonCalendar(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %#v, got %#v", want, got)
+	}
+
+	legacy.Season.Timezone = "America/Boise"
+	if got := legacy.SeasonCalendar().Timezone; got != "America/Boise" {
+		t.Errorf("Expected the season's timezone to be kept, got %s", got)
+	}
+
+	current := ScrapingConfig{Season: SeasonConfig{Closing: "2024-05-27"}, ClosingDate: "2024-04-21 5:00pm (MST)"}
+	if got := current.SeasonCalendar().Closing; got != "2024-05-27" {
+		t.Errorf("Expected season.closing to win, got %s", got)
+	}
+}
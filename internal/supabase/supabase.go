@@ -2,13 +2,13 @@ package supabase
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
 	"os"
-	"strings"
+	"strconv"
+	"time"
 
-	storage_go "github.com/supabase-community/storage-go"
 	"github.com/supabase-community/supabase-go"
+	"github.com/supabase/postgrest-go"
 )
 
 func NewSupabaseService() SupabaseClient {
@@ -16,19 +16,16 @@ func NewSupabaseService() SupabaseClient {
 	SUPABASE_SERVICE_ROLE_KEY := os.Getenv("SUPABASE_SERVICE_ROLE_KEY")
 	ENV := os.Getenv("ENV")
 
-	storageUrl := fmt.Sprintf("%s/storage/v1", SUPABASE_URL)
-	storageClient := storage_go.NewClient(storageUrl, SUPABASE_SERVICE_ROLE_KEY, nil)
-
 	if ENV == "production" {
 		client, clientErr := supabase.NewClient(SUPABASE_URL, SUPABASE_SERVICE_ROLE_KEY, nil)
 		if clientErr != nil {
 			log.Fatalf("Error creating supabase client: %s", clientErr)
 		}
 
-		return &SupabaseService{client, storageClient}
+		return &SupabaseService{client}
 	}
 
-	return &MockSupabaseService{storageClient}
+	return &MockSupabaseService{}
 }
 
 func (s *SupabaseService) UpsertResortConditionsData(data map[string]interface{}) error {
@@ -45,6 +42,14 @@ func (s *SupabaseService) InsertScrapingStatus(data ScrapingStatusData) error {
 		"success":      data.Success,
 		"error":        data.Error,
 	}
+	if data.Diagnostics != nil {
+		jsonData["diagnostics"] = data.Diagnostics
+		jsonData["drifting"] = len(data.DriftingFields) > 0
+		jsonData["drifting_fields"] = data.DriftingFields
+	}
+	if len(data.Artifacts) > 0 {
+		jsonData["artifacts"] = data.Artifacts
+	}
 	_, _, err := s.client.From("scraping_status").Insert(jsonData, false, "id", "*", "").Execute()
 	if err != nil {
 		log.Printf("Failed to insert scraping status: %s", err)
@@ -52,6 +57,242 @@ func (s *SupabaseService) InsertScrapingStatus(data ScrapingStatusData) error {
 	return err
 }
 
+// Returns the diagnostics of a resort's most recent scrapes, newest first.
+// Rows written before diagnostics were recorded are skipped
+func (s *SupabaseService) GetRecentScrapeDiagnostics(mountainName string, limit int) ([]ScrapeDiagnostics, error) {
+	data, _, err := s.client.From("scraping_status").
+		Select("diagnostics", "", false).
+		Eq("display_name", mountainName).
+		Order("id", &postgrest.OrderOpts{Ascending: false}).
+		Limit(limit, "").
+		Execute()
+	if err != nil {
+		log.Printf("Failed to get scraping status history: %s", err)
+		return nil, err
+	}
+
+	var rows []struct {
+		Diagnostics *ScrapeDiagnostics `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		log.Printf("Failed to unmarshal scraping status history: %s", err)
+		return nil, err
+	}
+
+	var history []ScrapeDiagnostics
+	for _, row := range rows {
+		if row.Diagnostics != nil {
+			history = append(history, *row.Diagnostics)
+		}
+	}
+	return history, nil
+}
+
+// Returns the resort's current conditions row, or nil before its first upsert
+func (s *SupabaseService) GetResortConditions(mountainID int) (*ResortConditions, error) {
+	data, _, err := s.client.From("resort_conditions").
+		Select("*", "", false).
+		Eq("mountain_id", strconv.Itoa(mountainID)).
+		Execute()
+	if err != nil {
+		log.Printf("Failed to get resort conditions: %s", err)
+		return nil, err
+	}
+
+	var rows []ResortConditions
+	if err := json.Unmarshal(data, &rows); err != nil {
+		log.Printf("Failed to unmarshal resort conditions: %s", err)
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+func (s *SupabaseService) InsertQuarantinedConditions(data QuarantinedConditions) error {
+	jsonData := map[string]interface{}{
+		"mountain_id":  data.MountainID,
+		"display_name": data.MountainName,
+		"conditions":   data.Conditions,
+		"reasons":      data.Reasons,
+	}
+	_, _, err := s.client.From("conditions_quarantine").Insert(jsonData, false, "", "*", "").Execute()
+	if err != nil {
+		log.Printf("Failed to insert quarantined conditions: %s", err)
+	}
+	return err
+}
+
+func (s *SupabaseService) InsertConditionsSnapshot(snapshot ConditionsSnapshot) error {
+	_, _, err := s.client.From("conditions_history").Insert(snapshot, false, "", "*", "").Execute()
+	if err != nil {
+		log.Printf("Failed to insert conditions snapshot: %s", err)
+	}
+	return err
+}
+
+// Returns the latest snapshot recorded at or before at, or nil when there is none
+func (s *SupabaseService) GetConditionsSnapshotAt(mountainID int, at time.Time) (*ConditionsSnapshot, error) {
+	data, _, err := s.client.From("conditions_history").
+		Select("*", "", false).
+		Eq("mountain_id", strconv.Itoa(mountainID)).
+		Lte("recorded_at", at.UTC().Format(time.RFC3339)).
+		Order("recorded_at", &postgrest.OrderOpts{Ascending: false}).
+		Limit(1, "").
+		Execute()
+	if err != nil {
+		log.Printf("Failed to get conditions snapshot: %s", err)
+		return nil, err
+	}
+
+	snapshots, err := unmarshalSnapshots(data)
+	if err != nil || len(snapshots) == 0 {
+		return nil, err
+	}
+	return &snapshots[0], nil
+}
+
+// Returns the snapshots recorded in [from, to), oldest first
+func (s *SupabaseService) GetConditionsSnapshots(mountainID int, from, to time.Time) ([]ConditionsSnapshot, error) {
+	data, _, err := s.client.From("conditions_history").
+		Select("*", "", false).
+		Eq("mountain_id", strconv.Itoa(mountainID)).
+		Gte("recorded_at", from.UTC().Format(time.RFC3339)).
+		Lt("recorded_at", to.UTC().Format(time.RFC3339)).
+		Order("recorded_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		log.Printf("Failed to get conditions snapshots: %s", err)
+		return nil, err
+	}
+	return unmarshalSnapshots(data)
+}
+
+// Returns every mountain's hourly snapshots recorded before the given time, oldest first
+func (s *SupabaseService) GetHourlyConditionsSnapshots(before time.Time) ([]ConditionsSnapshot, error) {
+	data, _, err := s.client.From("conditions_history").
+		Select("*", "", false).
+		Eq("resolution", ResolutionHourly).
+		Lt("recorded_at", before.UTC().Format(time.RFC3339)).
+		Order("recorded_at", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		log.Printf("Failed to get hourly conditions snapshots: %s", err)
+		return nil, err
+	}
+	return unmarshalSnapshots(data)
+}
+
+func (s *SupabaseService) MarkConditionsSnapshotsDaily(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, _, err := s.client.From("conditions_history").
+		Update(map[string]interface{}{"resolution": ResolutionDaily}, "", "").
+		In("id", idStrings(ids)).
+		Execute()
+	if err != nil {
+		log.Printf("Failed to mark conditions snapshots daily: %s", err)
+	}
+	return err
+}
+
+func (s *SupabaseService) DeleteConditionsSnapshots(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, _, err := s.client.From("conditions_history").
+		Delete("", "").
+		In("id", idStrings(ids)).
+		Execute()
+	if err != nil {
+		log.Printf("Failed to delete conditions snapshots: %s", err)
+	}
+	return err
+}
+
+// Returns the mountain's storm that hasn't ended yet, or nil when it isn't snowing
+func (s *SupabaseService) GetActiveStorm(mountainID int) (*Storm, error) {
+	data, _, err := s.client.From("storms").
+		Select("*", "", false).
+		Eq("mountain_id", strconv.Itoa(mountainID)).
+		Is("ended_at", "null").
+		Order("started_at", &postgrest.OrderOpts{Ascending: false}).
+		Limit(1, "").
+		Execute()
+	if err != nil {
+		log.Printf("Failed to get active storm: %s", err)
+		return nil, err
+	}
+
+	storms, err := unmarshalStorms(data)
+	if err != nil || len(storms) == 0 {
+		return nil, err
+	}
+	return &storms[0], nil
+}
+
+// Returns the mountain's storms that were still snowing at or after since, newest first
+func (s *SupabaseService) GetStorms(mountainID int, since time.Time) ([]Storm, error) {
+	data, _, err := s.client.From("storms").
+		Select("*", "", false).
+		Eq("mountain_id", strconv.Itoa(mountainID)).
+		Gte("last_snow_at", since.UTC().Format(time.RFC3339)).
+		Order("started_at", &postgrest.OrderOpts{Ascending: false}).
+		Execute()
+	if err != nil {
+		log.Printf("Failed to get storms: %s", err)
+		return nil, err
+	}
+	return unmarshalStorms(data)
+}
+
+func (s *SupabaseService) InsertStorm(storm Storm) error {
+	_, _, err := s.client.From("storms").Insert(storm, false, "", "*", "").Execute()
+	if err != nil {
+		log.Printf("Failed to insert storm: %s", err)
+	}
+	return err
+}
+
+func (s *SupabaseService) UpdateStorm(storm Storm) error {
+	_, _, err := s.client.From("storms").
+		Update(storm, "", "").
+		Eq("id", strconv.Itoa(storm.ID)).
+		Execute()
+	if err != nil {
+		log.Printf("Failed to update storm: %s", err)
+	}
+	return err
+}
+
+func unmarshalStorms(data []byte) ([]Storm, error) {
+	var storms []Storm
+	if err := json.Unmarshal(data, &storms); err != nil {
+		log.Printf("Failed to unmarshal storms: %s", err)
+		return nil, err
+	}
+	return storms, nil
+}
+
+func unmarshalSnapshots(data []byte) ([]ConditionsSnapshot, error) {
+	var snapshots []ConditionsSnapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		log.Printf("Failed to unmarshal conditions snapshots: %s", err)
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+func idStrings(ids []int) []string {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	return values
+}
+
 func (s *SupabaseService) GetUserOvernightAlerts() []UserOvernightAlert {
 	userAlertsResponse := s.client.Rpc("group_overnight_snowfall_alert_data", "", nil)
 
@@ -76,39 +317,16 @@ func (s *SupabaseService) GetUserForecastAlerts() []UserForecastAlert {
 	return userAlerts
 }
 
-func (s *SupabaseService) GetConfigByName(name string) ScrapingConfig {
-	fileName := fmt.Sprintf("%s.json", name)
-	log.Print(fileName)
-	result, err := s.storageClient.DownloadFile("scraping-config", fileName)
+// Returns each user subscribed to backcountry mountains, with the mountains
+func (s *SupabaseService) GetUserAvalancheSubscriptions() ([]UserAvalancheSubscriptions, error) {
+	response := s.client.Rpc("group_backcountry_avalanche_subscriptions", "", nil)
 
-	if err != nil {
-		log.Fatalf("Error fetching config by name: %v", err)
-	}
-	var config ScrapingConfig
-	jsonErr := json.Unmarshal([]byte(result), &config)
-	if jsonErr != nil {
-		log.Fatal(err)
-	}
-
-	return config
-}
-
-func (s *SupabaseService) GetAllMountainObjectNames() []string {
-	results, err := s.storageClient.ListFiles("scraping-config", "", storage_go.FileSearchOptions{
-		Limit: 50,
-	})
-
-	if err != nil {
-		log.Fatalf("Error getting all mountain object names: %v", err)
-	}
-	var names []string
-	for _, result := range results {
-		trimmedName := strings.Split(result.Name, ".")[0]
-		log.Print(trimmedName)
-		names = append(names, trimmedName)
+	var subscriptions []UserAvalancheSubscriptions
+	if err := json.Unmarshal([]byte(response), &subscriptions); err != nil {
+		log.Printf("Failed to unmarshal avalanche subscriptions: %s", err)
+		return nil, err
 	}
-
-	return names
+	return subscriptions, nil
 }
 
 func (s *SupabaseService) UpsertAvalancheForecast(data map[string]interface{}) error {
@@ -120,7 +338,7 @@ func (s *SupabaseService) UpsertAvalancheForecast(data map[string]interface{}) e
 }
 
 func (s *SupabaseService) GetMountainsWithAvalancheForecasts() ([]MountainCoordinates, error) {
-	data, _, err := s.client.From("mountains").Select("mountain_id, lat, lon", "", false).Eq("location_type", "backcountry").Execute()
+	data, _, err := s.client.From("mountains").Select("mountain_id, lat, lon, avalanche_center", "", false).Eq("location_type", "backcountry").Execute()
 	if err != nil {
 		log.Printf("Failed to get backcountry mountains: %s", err)
 		return nil, err
@@ -135,6 +353,65 @@ func (s *SupabaseService) GetMountainsWithAvalancheForecasts() ([]MountainCoordi
 	return mountains, nil
 }
 
+// Returns the issue date of the mountain's stored forecast, or nil when there
+// is none or it predates issue dates being saved as timestamps
+func (s *SupabaseService) GetAvalancheForecastIssueDate(mountainID int) (*time.Time, error) {
+	data, _, err := s.client.From("avalanche_forecasts").
+		Select("issue_date", "", false).
+		Eq("mountain_id", strconv.Itoa(mountainID)).
+		Execute()
+	if err != nil {
+		log.Printf("Failed to get avalanche forecast issue date: %s", err)
+		return nil, err
+	}
+
+	var rows []struct {
+		IssueDate string `json:"issue_date"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		log.Printf("Failed to unmarshal avalanche forecast issue date: %s", err)
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	issueDate, err := time.Parse(time.RFC3339, rows[0].IssueDate)
+	if err != nil {
+		return nil, nil
+	}
+	return &issueDate, nil
+}
+
+// Reissuing the same forecast updates its version instead of adding another
+func (s *SupabaseService) UpsertAvalancheForecastVersion(version AvalancheForecastVersion) error {
+	_, _, err := s.client.From("avalanche_forecast_versions").Upsert(version, "mountain_id,issue_date", "*", "").Execute()
+	if err != nil {
+		log.Printf("Failed to upsert avalanche forecast version: %s", err)
+	}
+	return err
+}
+
+// Returns the versions issued at or after since, oldest first
+func (s *SupabaseService) GetAvalancheForecastVersions(mountainID int, since time.Time) ([]AvalancheForecastVersion, error) {
+	data, _, err := s.client.From("avalanche_forecast_versions").
+		Select("*", "", false).
+		Eq("mountain_id", strconv.Itoa(mountainID)).
+		Gte("issue_date", since.UTC().Format(time.RFC3339)).
+		Order("issue_date", &postgrest.OrderOpts{Ascending: true}).
+		Execute()
+	if err != nil {
+		log.Printf("Failed to get avalanche forecast versions: %s", err)
+		return nil, err
+	}
+
+	var versions []AvalancheForecastVersion
+	if err := json.Unmarshal(data, &versions); err != nil {
+		log.Printf("Failed to unmarshal avalanche forecast versions: %s", err)
+		return nil, err
+	}
+	return versions, nil
+}
+
 /** Mock Supabase Service Implementations **/
 func (s *MockSupabaseService) UpsertResortConditionsData(data map[string]interface{}) error {
 	log.Printf("Mock upsert data: %v", data)
@@ -147,8 +424,9 @@ func (s *MockSupabaseService) GetUserOvernightAlerts() []UserOvernightAlert {
 			Email: "test@powderhound.io",
 			Alerts: []OvernightAlert{
 				{
-					Location: "Test Location",
-					Snowfall: 12,
+					MountainID: 1,
+					Location:   "Test Location",
+					Snowfall:   12,
 				},
 			},
 		},
@@ -169,49 +447,86 @@ func (s *MockSupabaseService) GetUserForecastAlerts() []UserForecastAlert {
 	}
 }
 
+func (s *MockSupabaseService) GetUserAvalancheSubscriptions() ([]UserAvalancheSubscriptions, error) {
+	return []UserAvalancheSubscriptions{
+		{
+			Email: "test@powderhound.io",
+			Mountains: []AvalancheSubscription{
+				{MountainID: 1, Location: "Loveland Pass"},
+				{MountainID: 3, Location: "Snoqualmie Pass"},
+			},
+		},
+	}, nil
+}
+
 func (s *MockSupabaseService) InsertScrapingStatus(data ScrapingStatusData) error {
 	log.Printf("Mock insert scraping status: %v", data)
 	return nil
 }
 
-func (s *MockSupabaseService) GetConfigByName(name string) ScrapingConfig {
-	fileName := fmt.Sprintf("%s.json", name)
-	log.Printf("Fetching config for mountain: %s", name)
-	log.Printf("Fetching config by formatted file name: %s", fileName)
-	result, err := s.storageClient.DownloadFile("scraping-config-dev", fileName)
+func (s *MockSupabaseService) GetRecentScrapeDiagnostics(mountainName string, limit int) ([]ScrapeDiagnostics, error) {
+	log.Printf("Mock get recent scrape diagnostics for %s", mountainName)
+	return nil, nil
+}
 
-	if err != nil {
-		log.Fatalf("Error fetching config by name: %v", err)
-	}
-	var config ScrapingConfig
-	jsonErr := json.Unmarshal([]byte(result), &config)
-	if jsonErr != nil {
-		log.Fatal(err)
-	}
+func (s *MockSupabaseService) GetResortConditions(mountainID int) (*ResortConditions, error) {
+	log.Printf("Mock get resort conditions for mountain %d", mountainID)
+	return nil, nil
+}
 
-	return config
+func (s *MockSupabaseService) InsertQuarantinedConditions(data QuarantinedConditions) error {
+	log.Printf("Mock insert quarantined conditions: %v", data)
+	return nil
 }
 
-func (s *MockSupabaseService) GetAllMountainObjectNames() []string {
-	results, err := s.storageClient.ListFiles("scraping-config-dev", "", storage_go.FileSearchOptions{
-		Limit: 50,
-	})
+func (s *MockSupabaseService) InsertConditionsSnapshot(snapshot ConditionsSnapshot) error {
+	log.Printf("Mock insert conditions snapshot: %v", snapshot)
+	return nil
+}
 
-	if err != nil {
-		log.Fatalf("Error getting all mountain object names: %v", err)
-	}
-	var names []string
-	for _, result := range results {
-		log.Printf("Result: %v", result.Name)
-		trimmedName := strings.Split(result.Name, ".")[0]
-		log.Print(trimmedName)
-		if trimmedName != "" {
-			names = append(names, trimmedName)
+func (s *MockSupabaseService) GetConditionsSnapshotAt(mountainID int, at time.Time) (*ConditionsSnapshot, error) {
+	log.Printf("Mock get conditions snapshot for mountain %d at %s", mountainID, at)
+	return nil, nil
+}
 
-		}
-	}
+func (s *MockSupabaseService) GetConditionsSnapshots(mountainID int, from, to time.Time) ([]ConditionsSnapshot, error) {
+	log.Printf("Mock get conditions snapshots for mountain %d from %s to %s", mountainID, from, to)
+	return nil, nil
+}
 
-	return names
+func (s *MockSupabaseService) GetHourlyConditionsSnapshots(before time.Time) ([]ConditionsSnapshot, error) {
+	log.Printf("Mock get hourly conditions snapshots before %s", before)
+	return nil, nil
+}
+
+func (s *MockSupabaseService) MarkConditionsSnapshotsDaily(ids []int) error {
+	log.Printf("Mock mark conditions snapshots daily: %v", ids)
+	return nil
+}
+
+func (s *MockSupabaseService) DeleteConditionsSnapshots(ids []int) error {
+	log.Printf("Mock delete conditions snapshots: %v", ids)
+	return nil
+}
+
+func (s *MockSupabaseService) GetActiveStorm(mountainID int) (*Storm, error) {
+	log.Printf("Mock get active storm for mountain %d", mountainID)
+	return nil, nil
+}
+
+func (s *MockSupabaseService) GetStorms(mountainID int, since time.Time) ([]Storm, error) {
+	log.Printf("Mock get storms for mountain %d since %s", mountainID, since)
+	return nil, nil
+}
+
+func (s *MockSupabaseService) InsertStorm(storm Storm) error {
+	log.Printf("Mock insert storm: %+v", storm)
+	return nil
+}
+
+func (s *MockSupabaseService) UpdateStorm(storm Storm) error {
+	log.Printf("Mock update storm: %+v", storm)
+	return nil
 }
 
 func (s *MockSupabaseService) UpsertAvalancheForecast(data map[string]interface{}) error {
@@ -222,7 +537,23 @@ func (s *MockSupabaseService) UpsertAvalancheForecast(data map[string]interface{
 func (s *MockSupabaseService) GetMountainsWithAvalancheForecasts() ([]MountainCoordinates, error) {
 	// Return mock data for development testing
 	return []MountainCoordinates{
-		{MountainID: 1, Lat: 39.6403, Lon: -105.8719}, // Loveland
-		{MountainID: 2, Lat: 39.4817, Lon: -106.0384}, // Breckenridge
+		{MountainID: 1, Lat: 39.6403, Lon: -105.8719},                          // Loveland
+		{MountainID: 2, Lat: 39.4817, Lon: -106.0384},                          // Breckenridge
+		{MountainID: 3, Lat: 47.4446, Lon: -121.4271, AvalancheCenter: "NWAC"}, // Snoqualmie Pass
 	}, nil
 }
+
+func (s *MockSupabaseService) GetAvalancheForecastIssueDate(mountainID int) (*time.Time, error) {
+	log.Printf("Mock get avalanche forecast issue date for mountain %d", mountainID)
+	return nil, nil
+}
+
+func (s *MockSupabaseService) UpsertAvalancheForecastVersion(version AvalancheForecastVersion) error {
+	log.Printf("Mock upsert avalanche forecast version for mountain %d issued %s", version.MountainID, version.IssueDate)
+	return nil
+}
+
+func (s *MockSupabaseService) GetAvalancheForecastVersions(mountainID int, since time.Time) ([]AvalancheForecastVersion, error) {
+	log.Printf("Mock get avalanche forecast versions for mountain %d since %s", mountainID, since)
+	return nil, nil
+}
@@ -3,11 +3,18 @@ package tasks
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"powderhoundgo/internal/avalanche"
+	"powderhoundgo/internal/configsource"
 	"powderhoundgo/internal/email"
+	"powderhoundgo/internal/history"
 	"powderhoundgo/internal/scraping"
+	"powderhoundgo/internal/storm"
 	"powderhoundgo/internal/supabase"
+	"strings"
+	"time"
 
 	"github.com/hibiken/asynq"
 )
@@ -18,9 +25,21 @@ func HandleResortWebScrapeTask(c context.Context, t *asynq.Task) error {
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
 		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
 	}
-	resortData, err := scraping.ScrapeResortData(&p.MountainName)
+	config, err := loadConfig(p.MountainName)
 	if err != nil {
-		scrapingData := supabase.ScrapingStatusData{MountainName: p.MountainName, Success: false, Error: err.Error()}
+		return fmt.Errorf("failed to load config for %s: %w", p.MountainName, err)
+	}
+	resortData, diagnostics, err := scraping.ScrapeResortData(p.MountainName, config)
+	driftingFields := checkSelectorDrift(supabaseClient, p.MountainName, diagnostics)
+	if err != nil {
+		scrapingData := supabase.ScrapingStatusData{
+			MountainName:   p.MountainName,
+			Success:        false,
+			Error:          err.Error(),
+			Diagnostics:    &diagnostics,
+			DriftingFields: driftingFields,
+			Artifacts:      scraping.FailureArtifacts(err),
+		}
 		err := supabaseClient.InsertScrapingStatus(scrapingData)
 		if err != nil {
 			log.Printf("failed to insert scraping status: %s", err)
@@ -28,12 +47,54 @@ func HandleResortWebScrapeTask(c context.Context, t *asynq.Task) error {
 		return fmt.Errorf("failed to scrape %s: %w", p.MountainName, err)
 	}
 
+	previous := loadResortConditions(supabaseClient, config)
+
+	// Implausible results are kept for review instead of overwriting the last good row
+	if reasons := scraping.ValidateConditions(config, resortData, previous); len(reasons) > 0 {
+		log.Printf("Quarantining conditions for %s: %s", p.MountainName, strings.Join(reasons, "; "))
+		err := supabaseClient.InsertQuarantinedConditions(supabase.QuarantinedConditions{
+			MountainID:   config.ID,
+			MountainName: p.MountainName,
+			Conditions:   resortData,
+			Reasons:      reasons,
+		})
+		if err != nil {
+			log.Printf("failed to insert quarantined conditions: %s", err)
+		}
+		scrapingData := supabase.ScrapingStatusData{
+			MountainName:   p.MountainName,
+			Success:        false,
+			Error:          "quarantined: " + strings.Join(reasons, "; "),
+			Diagnostics:    &diagnostics,
+			DriftingFields: driftingFields,
+		}
+		if err := supabaseClient.InsertScrapingStatus(scrapingData); err != nil {
+			log.Printf("failed to insert scraping status: %s", err)
+		}
+		// Scraping again straight away would most likely return the same values
+		return fmt.Errorf("quarantined conditions for %s: %w", p.MountainName, asynq.SkipRetry)
+	}
+
 	err = supabaseClient.UpsertResortConditionsData(resortData)
 	if err != nil {
 		return fmt.Errorf("failed to upsert conditions data %s", p.MountainName)
 	}
 
-	scrapingData := supabase.ScrapingStatusData{MountainName: p.MountainName, Success: true}
+	// History and storms are best effort, a failed write shouldn't retry a scrape that was already saved
+	now := time.Now()
+	if err := storm.Track(supabaseClient, config, previous, resortData, now); err != nil {
+		log.Printf("failed to track storm for %s: %s", p.MountainName, err)
+	}
+	if _, err := history.Record(supabaseClient, resortData, now); err != nil {
+		log.Printf("failed to record conditions history for %s: %s", p.MountainName, err)
+	}
+
+	scrapingData := supabase.ScrapingStatusData{
+		MountainName:   p.MountainName,
+		Success:        true,
+		Diagnostics:    &diagnostics,
+		DriftingFields: driftingFields,
+	}
 	err = supabaseClient.InsertScrapingStatus(scrapingData)
 	if err != nil {
 		log.Printf("failed to insert scraping status: %s", err)
@@ -43,6 +104,53 @@ func HandleResortWebScrapeTask(c context.Context, t *asynq.Task) error {
 	return nil
 }
 
+func loadConfig(name string) (supabase.ScrapingConfig, error) {
+	source, err := configsource.Default()
+	if err != nil {
+		return supabase.ScrapingConfig{}, err
+	}
+	return configsource.Load(source, name)
+}
+
+// Compares this run's field diagnostics with the resort's recent runs and
+// returns the fields whose selectors have stopped resolving
+func checkSelectorDrift(supabaseClient supabase.SupabaseClient, mountainName string, diagnostics supabase.ScrapeDiagnostics) []string {
+	history, err := supabaseClient.GetRecentScrapeDiagnostics(mountainName, scraping.DriftHistory)
+	if err != nil {
+		log.Printf("failed to load scraping history for %s: %s", mountainName, err)
+		return nil
+	}
+
+	driftingFields := scraping.DetectSelectorDrift(diagnostics, history, scraping.DriftRuns)
+	if len(driftingFields) > 0 {
+		log.Printf("Warning: %s is drifting, fields no longer resolving: %s", mountainName, strings.Join(driftingFields, ", "))
+	}
+	return driftingFields
+}
+
+// Loads the resort's current conditions, which a new scrape is validated and
+// tracked against. A failure to load them only skips the comparison with them
+func loadResortConditions(supabaseClient supabase.SupabaseClient, config supabase.ScrapingConfig) *supabase.ResortConditions {
+	previous, err := supabaseClient.GetResortConditions(config.ID)
+	if err != nil {
+		log.Printf("failed to load current conditions for %s: %s", config.Name, err)
+		return nil
+	}
+	return previous
+}
+
+// Downsamples hourly conditions history past the retention window to one
+// snapshot per day
+func HandleHistoryPruneTask(c context.Context, t *asynq.Task) error {
+	supabaseClient := supabase.NewSupabaseService()
+	if err := history.Prune(supabaseClient, time.Now()); err != nil {
+		return fmt.Errorf("failed to prune conditions history: %w", err)
+	}
+
+	log.Printf("Finished conditions history prune job")
+	return nil
+}
+
 func HandleAvalancheScrapingTask(c context.Context, t *asynq.Task) error {
 	supabaseClient := supabase.NewSupabaseService()
 	var p AvalancheScrapingPayload
@@ -51,44 +159,56 @@ func HandleAvalancheScrapingTask(c context.Context, t *asynq.Task) error {
 	}
 
 	mountain := scraping.MountainCoordinates{
-		MountainID: p.MountainID,
-		Lat:        p.Lat,
-		Lon:        p.Lon,
+		MountainID:      p.MountainID,
+		Lat:             p.Lat,
+		Lon:             p.Lon,
+		AvalancheCenter: p.AvalancheCenter,
+	}
+	mountains := p.Mountains
+	if len(mountains) == 0 {
+		mountains = []scraping.MountainCoordinates{mountain}
 	}
 
 	forecast, err := scraping.ScrapeAvalancheForecast(mountain)
 	if err != nil {
-		scrapingData := supabase.ScrapingStatusData{
-			MountainName: fmt.Sprintf("avalanche-%d", p.MountainID),
-			Success:      false,
-			Error:        err.Error(),
+		for _, m := range mountains {
+			scrapingData := supabase.ScrapingStatusData{
+				MountainName: fmt.Sprintf("avalanche-%d", m.MountainID),
+				Success:      false,
+				Error:        err.Error(),
+				Artifacts:    scraping.FailureArtifacts(err),
+			}
+			supabaseClient.InsertScrapingStatus(scrapingData)
 		}
-		supabaseClient.InsertScrapingStatus(scrapingData)
 		return fmt.Errorf("failed to scrape avalanche forecast for mountain %d: %w", p.MountainID, err)
 	}
 
-	// Convert forecast to map for upsert
-	forecastData := map[string]interface{}{
-		"mountain_id":          forecast.MountainID,
-		"avalanche_summary":    forecast.AvalancheSummary,
-		"issue_date":           forecast.IssueDate,
-		"overall_danger_level": forecast.OverallDangerLevel,
-		"danger_levels":        forecast.DangerLevels,
-		"forecast_url":         forecast.ForecastURL,
-		"updated_at":           forecast.UpdatedAt,
-	}
+	// Every mountain in the zone gets the same forecast
+	var errs []error
+	var mountainIDs []int
+	for _, m := range mountains {
+		mountainIDs = append(mountainIDs, m.MountainID)
 
-	err = supabaseClient.UpsertAvalancheForecast(forecastData)
-	if err != nil {
-		return fmt.Errorf("failed to upsert avalanche forecast for mountain %d: %w", p.MountainID, err)
-	}
+		// Unchanged forecasts aren't written again, the scrape still succeeded
+		if _, err := avalanche.Record(supabaseClient, forecast.ForMountain(m, p.ZoneID)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save avalanche forecast for mountain %d: %w", m.MountainID, err))
+			continue
+		}
 
-	scrapingData := supabase.ScrapingStatusData{
-		MountainName: fmt.Sprintf("avalanche-%d", p.MountainID),
-		Success:      true,
+		scrapingData := supabase.ScrapingStatusData{
+			MountainName: fmt.Sprintf("avalanche-%d", m.MountainID),
+			Success:      true,
+		}
+		supabaseClient.InsertScrapingStatus(scrapingData)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
 	}
-	supabaseClient.InsertScrapingStatus(scrapingData)
 
+	if p.ZoneID != "" {
+		log.Printf("Finished avalanche scraping job for zone %s, mountains %v", p.ZoneID, mountainIDs)
+		return nil
+	}
 	log.Printf("Finished avalanche scraping job for mountain %d", p.MountainID)
 	return nil
 }
@@ -117,3 +237,20 @@ func HandleForecastAlertEmailTask(c context.Context, t *asynq.Task) error {
 func HandleOvernightAlertEmailTask(c context.Context, t *asynq.Task) error {
 	return HandleAlertEmailTask(c, t, "PowderHound recent snowfall alert", email.BuildOvernightAlertEmail)
 }
+
+func HandleAvalancheAlertEmailTask(c context.Context, t *asynq.Task) error {
+	var p AvalancheAlertEmailPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+
+	emailBody := email.BuildAvalancheAlertEmail(p.Alerts)
+
+	resend := email.NewResendService()
+	err := resend.SendEmail("PowderHound avalanche danger alert", emailBody, p.Email)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
@@ -1,18 +1,32 @@
 package tasks
 
-import "powderhoundgo/internal/email"
+import (
+	"powderhoundgo/internal/email"
+	"powderhoundgo/internal/scraping"
+)
 
 type ResortWebScrapePayload struct {
 	MountainName string
 }
 
 type AvalancheScrapingPayload struct {
-	MountainID int
-	Lat        float64
-	Lon        float64
+	MountainID      int
+	Lat             float64
+	Lon             float64
+	AvalancheCenter string
+	// The forecast zone and every mountain in it, the forecast fetched for
+	// the coordinates above is saved for each. Without them only MountainID
+	// is saved
+	ZoneID    string
+	Mountains []scraping.MountainCoordinates
 }
 
 type AlertEmailPayload struct {
 	Email     string
 	EmailData []email.EmailData
 }
+
+type AvalancheAlertEmailPayload struct {
+	Email  string
+	Alerts []email.AvalancheAlertData
+}
@@ -12,6 +12,8 @@ const (
 	TypeAvalancheScrapingJob    = "scrape:avalanche"
 	TypeForecastAlertEmail      = "email:forecast"
 	TypeOvernightEmail          = "email:overnight"
+	TypeAvalancheAlertEmail     = "email:avalanche"
+	TypeHistoryPruneJob         = "history:prune"
 )
 
 func NewResortWebScrapeTask(name string) (*asynq.Task, error) {
@@ -23,11 +25,12 @@ func NewResortWebScrapeTask(name string) (*asynq.Task, error) {
 	return asynq.NewTask(TypeResortWebScrapingJob, payload), nil
 }
 
-func NewAvalancheScrapingTask(mountainID int, lat, lon float64) (*asynq.Task, error) {
+func NewAvalancheScrapingTask(mountainID int, lat, lon float64, avalancheCenter string) (*asynq.Task, error) {
 	payload, err := json.Marshal(AvalancheScrapingPayload{
-		MountainID: mountainID,
-		Lat:        lat,
-		Lon:        lon,
+		MountainID:      mountainID,
+		Lat:             lat,
+		Lon:             lon,
+		AvalancheCenter: avalancheCenter,
 	})
 	if err != nil {
 		return nil, err
@@ -52,3 +55,12 @@ func NewForecastAlertEmailTask(email string, emailData []email.EmailData) (*asyn
 func NewOvernightAlertEmailTask(email string, emailData []email.EmailData) (*asynq.Task, error) {
 	return NewAlertEmailTask(email, emailData, TypeOvernightEmail)
 }
+
+func NewAvalancheAlertEmailTask(email string, alerts []email.AvalancheAlertData) (*asynq.Task, error) {
+	payload, err := json.Marshal(AvalancheAlertEmailPayload{Email: email, Alerts: alerts})
+	if err != nil {
+		return nil, err
+	}
+
+	return asynq.NewTask(TypeAvalancheAlertEmail, payload), nil
+}
@@ -51,3 +51,15 @@ func TestNewOvernightAlertEmailTask(t *testing.T) {
 		t.Errorf("Expected task type %s, got %s", TypeOvernightEmail, task.Type())
 	}
 }
+
+func TestNewAvalancheAlertEmailTask(t *testing.T) {
+	alerts := []email.AvalancheAlertData{{Location: "Berthoud Pass", Rating: "Considerable", Level: 3}}
+	task, err := NewAvalancheAlertEmailTask("test@example.com", alerts)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if task.Type() != TypeAvalancheAlertEmail {
+		t.Errorf("Expected task type %s, got %s", TypeAvalancheAlertEmail, task.Type())
+	}
+}
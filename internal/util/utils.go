@@ -75,6 +75,11 @@ func addProductionScrapingCronTasks(cron *cron.Cron, client *asynq.Client, supab
 	cron.AddFunc("@hourly", func() {
 		queue.QueueAvalancheScrapingTasks(client, supabase)
 	})
+
+	// Downsample conditions history past the hourly retention window - 3:00am
+	cron.AddFunc("0 3 * * *", func() {
+		queue.QueueHistoryPruneTask(client)
+	})
 }
 
 func addDevelopmentScrapingCronTasks(cron *cron.Cron, client *asynq.Client, supabase supabase.SupabaseClient) {
@@ -83,6 +88,7 @@ func addDevelopmentScrapingCronTasks(cron *cron.Cron, client *asynq.Client, supa
 	cron.AddFunc("@every 5m", func() {
 		queue.QueueResortWebScrapeTasks(client, supabase)
 		queue.QueueAvalancheScrapingTasks(client, supabase)
+		queue.QueueHistoryPruneTask(client)
 	})
 }
 
@@ -96,6 +102,12 @@ func addProductionEmailCronTasks(cron *cron.Cron, client *asynq.Client, supabase
 	cron.AddFunc("5 6 * * *", func() {
 		queue.QueueOvernightAlertEmailTasks(client, supabase)
 	})
+
+	// Avalanche danger alert emails - 6:30pm, after the centers publish
+	// tomorrow's forecasts
+	cron.AddFunc("30 18 * * *", func() {
+		queue.QueueAvalancheAlertEmailTasks(client, supabase)
+	})
 }
 
 func addDevelopmentEmailCronTasks(cron *cron.Cron, client *asynq.Client, supabase supabase.SupabaseClient) {
@@ -106,6 +118,10 @@ func addDevelopmentEmailCronTasks(cron *cron.Cron, client *asynq.Client, supabas
 	cron.AddFunc("@every 1m", func() {
 		queue.QueueOvernightAlertEmailTasks(client, supabase)
 	})
+
+	cron.AddFunc("@every 1m", func() {
+		queue.QueueAvalancheAlertEmailTasks(client, supabase)
+	})
 }
 
 func printCronEntries(cronEntries []cron.Entry) {